@@ -0,0 +1,241 @@
+package phylum
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultJobPollInterval is how often AnalyzeLockfile polls for job completion when Wait is set
+const DefaultJobPollInterval = 5 * time.Second
+
+// AnalyzeLockfileOpts configures the AnalyzeLockfile pipeline
+type AnalyzeLockfileOpts struct {
+	ProjectName     string        // Name of the Phylum project to submit to
+	GroupName       string        // Optional group owning the project
+	Label           string        // Optional label for the job, most often a branch name
+	CreateIfMissing bool          // Create the project when it does not already exist
+	Wait            bool          // Poll until the job completes before returning
+	PollInterval    time.Duration // Interval between polls, defaults to DefaultJobPollInterval
+	AllowUnpinned   bool          // Submit the pinned packages of a manifest that also has unpinned requirements
+}
+
+// ThresholdViolation describes a package risk score that fell below a configured threshold
+type ThresholdViolation struct {
+	Name      string
+	Version   string
+	Type      PackageType
+	Domain    string
+	Score     float64
+	Threshold float32
+}
+
+// AnalyzeLockfileResult is the outcome of the AnalyzeLockfile pipeline
+type AnalyzeLockfileResult struct {
+	ProjectID  string
+	JobID      string
+	Job        *JobStatusResponseForPackageStatusExtended // nil unless the job was fetched
+	Complete   bool
+	Pass       bool
+	Action     Action
+	Violations []ThresholdViolation
+}
+
+// AnalyzeLockfile parses a lockfile, resolves (or creates) the target project, submits the packages
+// for analysis and, when opts.Wait is set, polls the job until it completes. Jobs are submitted as
+// user jobs, as AnalyzeParsedPackages does. ctx is checked before submitting and bounds the wait
+// for completion; the individual API requests do not take a context, so one already in flight
+// runs to completion.
+func (p *PhylumClient) AnalyzeLockfile(ctx context.Context, lockfilePath string, opts AnalyzeLockfileOpts) (*AnalyzeLockfileResult, error) {
+	if opts.ProjectName == "" {
+		return nil, fmt.Errorf("AnalyzeLockfile(): ProjectName is required")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("AnalyzeLockfile(): %w", err)
+	}
+
+	packages, err := p.ParseLockfileWithOptions(lockfilePath, &ParseOptions{Offline: p.DisableRemoteParse, AllowUnpinned: opts.AllowUnpinned})
+	if err != nil {
+		return nil, fmt.Errorf("AnalyzeLockfile(): failed to parse lockfile: %w", err)
+	}
+	if len(*packages) == 0 {
+		return nil, fmt.Errorf("AnalyzeLockfile(): no packages found in %v", lockfilePath)
+	}
+
+	projectID, err := p.resolveProject(opts.ProjectName, opts.GroupName, opts.CreateIfMissing)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("AnalyzeLockfile(): %w", err)
+	}
+
+	submitPackageRequest := SubmitPackageRequest{
+		IsUser:   true,
+		Label:    opts.Label,
+		Packages: *packages,
+		Project:  projectID,
+		Type:     string((*packages)[0].Type),
+	}
+	if opts.GroupName != "" {
+		groupName := opts.GroupName
+		submitPackageRequest.GroupName = &groupName
+	}
+
+	jobID, err := p.submitPackages(submitPackageRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AnalyzeLockfileResult{
+		ProjectID: projectID,
+		JobID:     jobID,
+	}
+	if !opts.Wait {
+		return result, nil
+	}
+
+	job, err := p.WaitForJob(ctx, jobID, opts.PollInterval)
+	if err != nil {
+		return result, err
+	}
+	result.Job = job
+	result.Complete = true
+	result.Pass = job.Pass
+	result.Action = JobAction(job)
+	result.Violations = JobThresholdViolations(job)
+
+	return result, nil
+}
+
+// WaitForJob polls GetJobVerbose until the job reports a complete status or ctx is done.
+func (p *PhylumClient) WaitForJob(ctx context.Context, jobID string, interval time.Duration) (*JobStatusResponseForPackageStatusExtended, error) {
+	if interval <= 0 {
+		interval = DefaultJobPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		job, _, err := p.GetJobVerbose(jobID)
+		if err != nil {
+			return nil, err
+		}
+		if JobComplete(job) {
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return job, fmt.Errorf("WaitForJob(): job %v did not complete: %w", jobID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// resolveProject finds a project by name, optionally within a group, creating it when allowed
+func (p *PhylumClient) resolveProject(name string, groupName string, create bool) (string, error) {
+	var projects []ProjectSummaryResponse
+	var err error
+
+	if groupName != "" {
+		projects, err = p.ListGroupProjects(groupName)
+	} else {
+		projects, err = p.ListProjects()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for _, proj := range projects {
+		if proj.Name == name {
+			return proj.Id.String(), nil
+		}
+	}
+
+	if !create {
+		return "", fmt.Errorf("resolveProject(): project %v not found", name)
+	}
+
+	created, err := p.CreateProject(name, &ProjectOpts{GroupName: groupName})
+	if err != nil {
+		return "", err
+	}
+	return created.Id.String(), nil
+}
+
+// JobComplete reports whether the job status is complete
+func JobComplete(job *JobStatusResponseForPackageStatusExtended) bool {
+	return job != nil && fmt.Sprintf("%v", job.Status) == string(Complete)
+}
+
+// JobAction returns the action the server attached to the job, or ActionNone if it is unset
+func JobAction(job *JobStatusResponseForPackageStatusExtended) Action {
+	if job == nil || job.Action == nil {
+		return ActionNone
+	}
+	return Action(fmt.Sprintf("%v", job.Action))
+}
+
+// JobThresholdViolations lists the package risk vectors that fall below the job's active thresholds.
+// A threshold of zero is treated as disabled.
+func JobThresholdViolations(job *JobStatusResponseForPackageStatusExtended) []ThresholdViolation {
+	var violations []ThresholdViolation
+	if job == nil {
+		return nil
+	}
+
+	for _, pkg := range job.Packages {
+		if pkg.PackageScore != nil && job.Thresholds.Total > 0 && *pkg.PackageScore < float64(job.Thresholds.Total) {
+			violations = append(violations, ThresholdViolation{
+				Name:      pkg.Name,
+				Version:   pkg.Version,
+				Type:      jobPackageType(pkg.Type),
+				Domain:    "total",
+				Score:     *pkg.PackageScore,
+				Threshold: job.Thresholds.Total,
+			})
+		}
+		for _, key := range sortedKeys(pkg.RiskVectors.AdditionalProperties) {
+			domain, ok := riskVectorDomain(key)
+			if !ok {
+				continue
+			}
+			threshold := jobThreshold(job, domain)
+			if threshold <= 0 {
+				continue
+			}
+			score := pkg.RiskVectors.AdditionalProperties[key]
+			if score < float64(threshold) {
+				violations = append(violations, ThresholdViolation{
+					Name:      pkg.Name,
+					Version:   pkg.Version,
+					Type:      jobPackageType(pkg.Type),
+					Domain:    key,
+					Score:     score,
+					Threshold: threshold,
+				})
+			}
+		}
+	}
+
+	return violations
+}
+
+// jobThreshold returns the job's threshold for a risk domain
+func jobThreshold(job *JobStatusResponseForPackageStatusExtended, domain RiskDomain) float32 {
+	switch domain {
+	case RiskDomainAuthor:
+		return job.Thresholds.Author
+	case RiskDomainEngineering:
+		return job.Thresholds.Engineering
+	case RiskDomainLicense:
+		return job.Thresholds.License
+	case RiskDomainMaliciousCode:
+		return job.Thresholds.Malicious
+	case RiskDomainVulnerability:
+		return job.Thresholds.Vulnerability
+	}
+	return 0
+}
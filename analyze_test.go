@@ -0,0 +1,66 @@
+package phylum
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJobThresholdViolations(t *testing.T) {
+	lowScore := 0.4
+	highScore := 0.9
+
+	job := &JobStatusResponseForPackageStatusExtended{
+		Packages: []PackageStatusExtended{
+			{
+				Name:         "left-pad",
+				Version:      "1.0.0",
+				PackageScore: &lowScore,
+				RiskVectors: PackageStatusExtended_RiskVectors{AdditionalProperties: map[string]float64{
+					"author":        0.2,
+					"vulnerability": 0.9,
+				}},
+			},
+			{
+				Name:         "lodash",
+				Version:      "4.17.21",
+				PackageScore: &highScore,
+				RiskVectors: PackageStatusExtended_RiskVectors{AdditionalProperties: map[string]float64{
+					"author": 0.9,
+				}},
+			},
+		},
+	}
+	job.Thresholds.Total = 0.6
+	job.Thresholds.Author = 0.5
+	job.Thresholds.Vulnerability = 0.5
+
+	want := []ThresholdViolation{
+		{Name: "left-pad", Version: "1.0.0", Domain: "total", Score: 0.4, Threshold: 0.6},
+		{Name: "left-pad", Version: "1.0.0", Domain: "author", Score: 0.2, Threshold: 0.5},
+	}
+
+	got := JobThresholdViolations(job)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("JobThresholdViolations() got = %v, want %v", got, want)
+	}
+}
+
+func TestJobAction(t *testing.T) {
+	tests := []struct {
+		name   string
+		action interface{}
+		want   Action
+	}{
+		{"unset", nil, ActionNone},
+		{"break", "break", ActionBreak},
+		{"warn", "warn", ActionWarn},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &JobStatusResponseForPackageStatusExtended{Action: tt.action}
+			if got := JobAction(job); got != tt.want {
+				t.Errorf("JobAction() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
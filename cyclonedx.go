@@ -0,0 +1,504 @@
+package phylum
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CycloneDXSpecVersion is the CycloneDX specification version written by the exporter
+const CycloneDXSpecVersion = "1.5"
+
+const cyclonedxXMLNamespace = "http://cyclonedx.org/schema/bom/1.5"
+
+// CycloneDXOptions control the metadata of an exported BOM
+type CycloneDXOptions struct {
+	Name         string           // Name of the application the BOM describes, defaults to the project name
+	Version      string           // Version of the application
+	SerialNumber string           // urn:uuid serial number, omitted when empty so output is reproducible
+	Timestamp    time.Time        // Creation time, omitted when zero
+	Graph        *DependencyGraph // Dependency relationships for NewCycloneDXFromPackages
+}
+
+// CycloneDXBOM is a CycloneDX 1.5 bill of materials. Its fields follow the JSON format; WriteXML
+// converts it to the XML format.
+type CycloneDXBOM struct {
+	BOMFormat       string                   `json:"bomFormat"`
+	SpecVersion     string                   `json:"specVersion"`
+	SerialNumber    string                   `json:"serialNumber,omitempty"`
+	Version         int                      `json:"version"`
+	Metadata        *CycloneDXMetadata       `json:"metadata,omitempty"`
+	Components      []CycloneDXComponent     `json:"components"`
+	Dependencies    []CycloneDXDependency    `json:"dependencies,omitempty"`
+	Vulnerabilities []CycloneDXVulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// CycloneDXMetadata describes the BOM itself
+type CycloneDXMetadata struct {
+	Timestamp string              `json:"timestamp,omitempty"`
+	Tools     *CycloneDXTools     `json:"tools,omitempty"`
+	Component *CycloneDXComponent `json:"component,omitempty"`
+}
+
+// CycloneDXTools lists the tools that created the BOM
+type CycloneDXTools struct {
+	Components []CycloneDXComponent `json:"components"`
+}
+
+// CycloneDXComponent is a package in the BOM
+type CycloneDXComponent struct {
+	BOMRef     string                   `json:"bom-ref,omitempty"`
+	Type       string                   `json:"type"`
+	Group      string                   `json:"group,omitempty"`
+	Name       string                   `json:"name"`
+	Version    string                   `json:"version,omitempty"`
+	Licenses   []CycloneDXLicenseChoice `json:"licenses,omitempty"`
+	Purl       string                   `json:"purl,omitempty"`
+	Properties []CycloneDXProperty      `json:"properties,omitempty"`
+}
+
+// CycloneDXLicenseChoice holds either a single license or an SPDX expression
+type CycloneDXLicenseChoice struct {
+	License    *CycloneDXLicense `json:"license,omitempty"`
+	Expression string            `json:"expression,omitempty"`
+}
+
+// CycloneDXLicense is a license by SPDX ID or, for non-SPDX licenses, by name
+type CycloneDXLicense struct {
+	ID   string `json:"id,omitempty" xml:"id,omitempty"`
+	Name string `json:"name,omitempty" xml:"name,omitempty"`
+}
+
+// CycloneDXDependency lists the components a component depends on
+type CycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// CycloneDXVulnerability is an issue affecting one or more components
+type CycloneDXVulnerability struct {
+	BOMRef         string              `json:"bom-ref,omitempty"`
+	ID             string              `json:"id,omitempty"`
+	Source         *CycloneDXSource    `json:"source,omitempty"`
+	Ratings        []CycloneDXRating   `json:"ratings,omitempty"`
+	Description    string              `json:"description,omitempty"`
+	Detail         string              `json:"detail,omitempty"`
+	Recommendation string              `json:"recommendation,omitempty"`
+	Affects        []CycloneDXAffect   `json:"affects,omitempty"`
+	Properties     []CycloneDXProperty `json:"properties,omitempty"`
+}
+
+// CycloneDXSource names the source of a vulnerability
+type CycloneDXSource struct {
+	Name string `json:"name,omitempty" xml:"name,omitempty"`
+	URL  string `json:"url,omitempty" xml:"url,omitempty"`
+}
+
+// CycloneDXRating is the severity of a vulnerability
+type CycloneDXRating struct {
+	Source   *CycloneDXSource `json:"source,omitempty"`
+	Severity string           `json:"severity,omitempty"`
+	Method   string           `json:"method,omitempty"`
+}
+
+// CycloneDXAffect references a component affected by a vulnerability
+type CycloneDXAffect struct {
+	Ref string `json:"ref"`
+}
+
+// CycloneDXProperty is a name/value pair
+type CycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+var phylumSource = &CycloneDXSource{Name: "Phylum", URL: "https://phylum.io"}
+
+// NewCycloneDXFromPackages builds a BOM from parsed lockfile packages. Dependency relationships
+// are included when opts.Graph is set.
+func NewCycloneDXFromPackages(packages []PackageDescriptor, opts *CycloneDXOptions) *CycloneDXBOM {
+	if opts == nil {
+		opts = &CycloneDXOptions{}
+	}
+	return newCycloneDX(sbomPackagesFromDescriptors(packages), opts.Graph, opts)
+}
+
+// NewCycloneDXFromJob builds a BOM from a verbose job, including licenses, the dependency
+// relationships recorded by the job and the issues of each package as vulnerabilities
+func NewCycloneDXFromJob(job *JobStatusResponseForPackageStatusExtended, opts *CycloneDXOptions) *CycloneDXBOM {
+	o := CycloneDXOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if job == nil {
+		return newCycloneDX(nil, nil, &o)
+	}
+	if o.Name == "" {
+		o.Name = job.ProjectName
+	}
+	if o.Version == "" && job.Label != nil {
+		o.Version = *job.Label
+	}
+	return newCycloneDX(sbomPackagesFromJob(job), NewDependencyGraphFromJob(job), &o)
+}
+
+// NewCycloneDXFromProject builds a BOM from the dependencies of a project
+func NewCycloneDXFromProject(project *ProjectResponse, opts *CycloneDXOptions) *CycloneDXBOM {
+	o := CycloneDXOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if project == nil {
+		return newCycloneDX(nil, nil, &o)
+	}
+	if o.Name == "" {
+		o.Name = project.Name
+	}
+	if o.Version == "" && project.Label != nil {
+		o.Version = *project.Label
+	}
+	return newCycloneDX(sbomPackagesFromProject(project), NewDependencyGraphFromProject(project), &o)
+}
+
+func newCycloneDX(pkgs []sbomPackage, graph *DependencyGraph, opts *CycloneDXOptions) *CycloneDXBOM {
+	bom := &CycloneDXBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  CycloneDXSpecVersion,
+		SerialNumber: opts.SerialNumber,
+		Version:      1,
+		Components:   []CycloneDXComponent{},
+		Metadata: &CycloneDXMetadata{
+			Tools: &CycloneDXTools{Components: []CycloneDXComponent{{Type: "library", Name: "go-phylum"}}},
+		},
+	}
+	if !opts.Timestamp.IsZero() {
+		bom.Metadata.Timestamp = opts.Timestamp.UTC().Format(time.RFC3339)
+	}
+	if opts.Name != "" {
+		bom.Metadata.Component = &CycloneDXComponent{BOMRef: opts.Name, Type: "application", Name: opts.Name, Version: opts.Version}
+	}
+
+	refs := make(map[PackageDescriptor]string, len(pkgs))
+	vulnerabilities := make(map[string]int)
+	for _, pkg := range pkgs {
+		ref := cyclonedxRef(pkg.PackageDescriptor)
+		refs[pkg.PackageDescriptor] = ref
+		bom.Components = append(bom.Components, cyclonedxComponent(pkg, ref))
+
+		for _, issue := range pkg.Issues {
+			key := issueID(issue)
+			if key == "" {
+				key = issue.Title
+			}
+			if idx, ok := vulnerabilities[key]; ok {
+				bom.Vulnerabilities[idx].Affects = append(bom.Vulnerabilities[idx].Affects, CycloneDXAffect{Ref: ref})
+				continue
+			}
+			vulnerabilities[key] = len(bom.Vulnerabilities)
+			bom.Vulnerabilities = append(bom.Vulnerabilities, cyclonedxVulnerability(issue, ref))
+		}
+	}
+
+	if graph != nil {
+		if root := bom.Metadata.Component; root != nil {
+			bom.Dependencies = append(bom.Dependencies, CycloneDXDependency{Ref: root.BOMRef, DependsOn: cyclonedxRefs(graph.DirectPackages(), refs)})
+		}
+		for _, pkg := range pkgs {
+			bom.Dependencies = append(bom.Dependencies, CycloneDXDependency{
+				Ref:       refs[pkg.PackageDescriptor],
+				DependsOn: cyclonedxRefs(graph.Dependencies(pkg.PackageDescriptor), refs),
+			})
+		}
+	}
+	return bom
+}
+
+// cyclonedxRef identifies a component by purl, or by name and version when it has no purl
+func cyclonedxRef(pkg PackageDescriptor) string {
+	if purl := PackageURL(pkg); purl != "" {
+		return purl
+	}
+	return pkg.Name + "@" + pkg.Version
+}
+
+func cyclonedxRefs(pkgs []PackageDescriptor, refs map[PackageDescriptor]string) []string {
+	var result []string
+	for _, pkg := range pkgs {
+		if ref, ok := refs[pkg]; ok {
+			result = append(result, ref)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func cyclonedxComponent(pkg sbomPackage, ref string) CycloneDXComponent {
+	component := CycloneDXComponent{
+		BOMRef:  ref,
+		Type:    "library",
+		Name:    pkg.Name,
+		Version: pkg.Version,
+		Purl:    PackageURL(pkg.PackageDescriptor),
+	}
+	if namespace, name := purlNamespace(pkg.PackageDescriptor); namespace != "" && pkg.Type != Pypi {
+		component.Group, component.Name = namespace, name
+	}
+	if pkg.License != nil && strings.TrimSpace(*pkg.License) != "" {
+		component.Licenses = []CycloneDXLicenseChoice{cyclonedxLicense(*pkg.License)}
+	}
+	if pkg.RepoUrl != nil && *pkg.RepoUrl != "" {
+		component.Properties = append(component.Properties, CycloneDXProperty{Name: "phylum:repo_url", Value: *pkg.RepoUrl})
+	}
+	return component
+}
+
+// cyclonedxLicense uses an SPDX ID for single known licenses, an expression for compound ones
+// and the license name for anything that isn't valid SPDX
+func cyclonedxLicense(license string) CycloneDXLicenseChoice {
+	expr, err := ParseLicenseExpression(license)
+	switch {
+	case err != nil:
+		return CycloneDXLicenseChoice{License: &CycloneDXLicense{Name: license}}
+	case expr.Op != "" || expr.Exception != "":
+		return CycloneDXLicenseChoice{Expression: expr.String()}
+	case LicenseCategoryOf(expr.License) == LicenseUnknown:
+		return CycloneDXLicenseChoice{License: &CycloneDXLicense{Name: license}}
+	}
+	return CycloneDXLicenseChoice{License: &CycloneDXLicense{ID: expr.License}}
+}
+
+func cyclonedxVulnerability(issue Issue, ref string) CycloneDXVulnerability {
+	vulnerability := CycloneDXVulnerability{
+		ID:          issueID(issue),
+		Source:      phylumSource,
+		Ratings:     []CycloneDXRating{{Source: phylumSource, Severity: CycloneDXSeverity(issue.Severity), Method: "other"}},
+		Description: issue.Title,
+		Detail:      issue.Description,
+		Affects:     []CycloneDXAffect{{Ref: ref}},
+	}
+	vulnerability.Recommendation = ParseRemediation(issue.Description).Recommendation
+	if issue.Domain != "" {
+		vulnerability.Properties = []CycloneDXProperty{{Name: "phylum:domain", Value: string(issue.Domain)}}
+	}
+	return vulnerability
+}
+
+// CycloneDXSeverity maps a RiskLevel to a CycloneDX severity
+func CycloneDXSeverity(level RiskLevel) string {
+	switch level {
+	case Critical:
+		return "critical"
+	case High:
+		return "high"
+	case Medium:
+		return "medium"
+	case Low:
+		return "low"
+	case Info:
+		return "info"
+	}
+	return "unknown"
+}
+
+// WriteJSON writes the BOM in the CycloneDX JSON format
+func (b *CycloneDXBOM) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(b); err != nil {
+		return fmt.Errorf("WriteJSON(): %w", err)
+	}
+	return nil
+}
+
+// WriteXML writes the BOM in the CycloneDX XML format
+func (b *CycloneDXBOM) WriteXML(w io.Writer) error {
+	doc := cdxXMLBOM{
+		Xmlns:        cyclonedxXMLNamespace,
+		SerialNumber: b.SerialNumber,
+		Version:      b.Version,
+	}
+	if b.Metadata != nil {
+		doc.Metadata = &cdxXMLMetadata{Timestamp: b.Metadata.Timestamp}
+		if b.Metadata.Tools != nil {
+			doc.Metadata.Tools = &cdxXMLTools{Components: cdxXMLComponents(b.Metadata.Tools.Components)}
+		}
+		if b.Metadata.Component != nil {
+			component := cdxXMLComponentFrom(*b.Metadata.Component)
+			doc.Metadata.Component = &component
+		}
+	}
+	doc.Components = cdxXMLComponents(b.Components)
+	if len(b.Dependencies) > 0 {
+		doc.Dependencies = &cdxXMLDependencies{}
+	}
+	for _, dep := range b.Dependencies {
+		xmlDep := cdxXMLDependency{Ref: dep.Ref}
+		for _, ref := range dep.DependsOn {
+			xmlDep.Dependencies = append(xmlDep.Dependencies, cdxXMLDependency{Ref: ref})
+		}
+		doc.Dependencies.Dependencies = append(doc.Dependencies.Dependencies, xmlDep)
+	}
+	if len(b.Vulnerabilities) > 0 {
+		doc.Vulnerabilities = &cdxXMLVulnerabilities{}
+	}
+	for _, v := range b.Vulnerabilities {
+		xmlVuln := cdxXMLVulnerability{
+			BOMRef:         v.BOMRef,
+			ID:             v.ID,
+			Source:         v.Source,
+			Description:    v.Description,
+			Detail:         v.Detail,
+			Recommendation: v.Recommendation,
+			Properties:     cdxXMLPropertiesFrom(v.Properties),
+		}
+		for _, rating := range v.Ratings {
+			xmlVuln.Ratings = append(xmlVuln.Ratings, cdxXMLRating{Source: rating.Source, Severity: rating.Severity, Method: rating.Method})
+		}
+		for _, affect := range v.Affects {
+			xmlVuln.Affects = append(xmlVuln.Affects, cdxXMLTarget{Ref: affect.Ref})
+		}
+		doc.Vulnerabilities.Vulnerabilities = append(doc.Vulnerabilities.Vulnerabilities, xmlVuln)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("WriteXML(): %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("WriteXML(): %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("WriteXML(): %w", err)
+	}
+	return nil
+}
+
+// The XML format nests lists in wrapper elements and uses attributes for references, so it has
+// its own set of types
+type cdxXMLBOM struct {
+	XMLName         xml.Name               `xml:"bom"`
+	Xmlns           string                 `xml:"xmlns,attr"`
+	SerialNumber    string                 `xml:"serialNumber,attr,omitempty"`
+	Version         int                    `xml:"version,attr"`
+	Metadata        *cdxXMLMetadata        `xml:"metadata,omitempty"`
+	Components      []cdxXMLComponent      `xml:"components>component"`
+	Dependencies    *cdxXMLDependencies    `xml:"dependencies,omitempty"`
+	Vulnerabilities *cdxXMLVulnerabilities `xml:"vulnerabilities,omitempty"`
+}
+
+type cdxXMLDependencies struct {
+	Dependencies []cdxXMLDependency `xml:"dependency"`
+}
+
+type cdxXMLVulnerabilities struct {
+	Vulnerabilities []cdxXMLVulnerability `xml:"vulnerability"`
+}
+
+type cdxXMLMetadata struct {
+	Timestamp string           `xml:"timestamp,omitempty"`
+	Tools     *cdxXMLTools     `xml:"tools,omitempty"`
+	Component *cdxXMLComponent `xml:"component,omitempty"`
+}
+
+type cdxXMLTools struct {
+	Components []cdxXMLComponent `xml:"components>component"`
+}
+
+type cdxXMLComponent struct {
+	Type       string            `xml:"type,attr"`
+	BOMRef     string            `xml:"bom-ref,attr,omitempty"`
+	Group      string            `xml:"group,omitempty"`
+	Name       string            `xml:"name"`
+	Version    string            `xml:"version,omitempty"`
+	Licenses   *cdxXMLLicenses   `xml:"licenses,omitempty"`
+	Purl       string            `xml:"purl,omitempty"`
+	Properties *cdxXMLProperties `xml:"properties,omitempty"`
+}
+
+type cdxXMLLicenses struct {
+	Licenses   []CycloneDXLicense `xml:"license"`
+	Expression string             `xml:"expression,omitempty"`
+}
+
+type cdxXMLProperties struct {
+	Properties []cdxXMLProperty `xml:"property"`
+}
+
+type cdxXMLProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+type cdxXMLDependency struct {
+	Ref          string             `xml:"ref,attr"`
+	Dependencies []cdxXMLDependency `xml:"dependency"`
+}
+
+type cdxXMLVulnerability struct {
+	BOMRef         string            `xml:"bom-ref,attr,omitempty"`
+	ID             string            `xml:"id,omitempty"`
+	Source         *CycloneDXSource  `xml:"source,omitempty"`
+	Ratings        []cdxXMLRating    `xml:"ratings>rating"`
+	Description    string            `xml:"description,omitempty"`
+	Detail         string            `xml:"detail,omitempty"`
+	Recommendation string            `xml:"recommendation,omitempty"`
+	Affects        []cdxXMLTarget    `xml:"affects>target"`
+	Properties     *cdxXMLProperties `xml:"properties,omitempty"`
+}
+
+type cdxXMLRating struct {
+	Source   *CycloneDXSource `xml:"source,omitempty"`
+	Severity string           `xml:"severity,omitempty"`
+	Method   string           `xml:"method,omitempty"`
+}
+
+type cdxXMLTarget struct {
+	Ref string `xml:"ref"`
+}
+
+func cdxXMLComponents(components []CycloneDXComponent) []cdxXMLComponent {
+	var result []cdxXMLComponent
+	for _, component := range components {
+		result = append(result, cdxXMLComponentFrom(component))
+	}
+	return result
+}
+
+func cdxXMLComponentFrom(c CycloneDXComponent) cdxXMLComponent {
+	component := cdxXMLComponent{
+		Type:       c.Type,
+		BOMRef:     c.BOMRef,
+		Group:      c.Group,
+		Name:       c.Name,
+		Version:    c.Version,
+		Purl:       c.Purl,
+		Properties: cdxXMLPropertiesFrom(c.Properties),
+	}
+	if len(c.Licenses) > 0 {
+		component.Licenses = &cdxXMLLicenses{}
+		for _, choice := range c.Licenses {
+			if choice.License != nil {
+				component.Licenses.Licenses = append(component.Licenses.Licenses, *choice.License)
+			} else {
+				component.Licenses.Expression = choice.Expression
+			}
+		}
+	}
+	return component
+}
+
+func cdxXMLPropertiesFrom(properties []CycloneDXProperty) *cdxXMLProperties {
+	if len(properties) == 0 {
+		return nil
+	}
+	result := &cdxXMLProperties{}
+	for _, property := range properties {
+		result.Properties = append(result.Properties, cdxXMLProperty{Name: property.Name, Value: property.Value})
+	}
+	return result
+}
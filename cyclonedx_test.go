@@ -0,0 +1,118 @@
+package phylum
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testSBOMJob() *JobStatusResponseForPackageStatusExtended {
+	license := func(s string) *string { return &s }
+	tag := "HV00001"
+	label := "main"
+	return &JobStatusResponseForPackageStatusExtended{
+		ProjectName: "web",
+		Label:       &label,
+		Packages: []PackageStatusExtended{
+			{
+				Name: "express", Version: "4.18.2", Type: "npm", License: license("MIT"),
+				Dependencies: PackageStatusExtended_Dependencies{AdditionalProperties: map[string]string{"qs": "6.11.0"}},
+			},
+			{
+				Name: "qs", Version: "6.11.0", Type: "npm", License: license("BSD-3-Clause OR MIT"),
+				Issues: []Issue{{
+					Title:       "Prototype pollution",
+					Description: "qs is vulnerable.\n\n### Recommendation\nUpgrade to 6.11.1\n\n### References\n- https://github.com/advisories",
+					Tag:         &tag,
+					Domain:      RiskDomainVulnerability,
+					Severity:    High,
+				}},
+			},
+			{Name: "@types/node", Version: "18.0.0", Type: "npm", License: license("Custom EULA")},
+		},
+	}
+}
+
+func TestNewCycloneDXFromJob(t *testing.T) {
+	bom := NewCycloneDXFromJob(testSBOMJob(), nil)
+
+	var refs []string
+	for _, component := range bom.Components {
+		refs = append(refs, component.BOMRef)
+	}
+	wantRefs := []string{"pkg:npm/%40types/node@18.0.0", "pkg:npm/express@4.18.2", "pkg:npm/qs@6.11.0"}
+	if !reflect.DeepEqual(refs, wantRefs) {
+		t.Errorf("NewCycloneDXFromJob() components got = %v, want %v", refs, wantRefs)
+	}
+	if c := bom.Components[0]; c.Group != "@types" || c.Name != "node" || c.Licenses[0].License.Name != "Custom EULA" {
+		t.Errorf("NewCycloneDXFromJob() scoped component got = %+v", c)
+	}
+	if got := bom.Components[1].Licenses[0].License.ID; got != "MIT" {
+		t.Errorf("NewCycloneDXFromJob() license id got = %v, want MIT", got)
+	}
+	if got := bom.Components[2].Licenses[0].Expression; got != "BSD-3-Clause OR MIT" {
+		t.Errorf("NewCycloneDXFromJob() license expression got = %v", got)
+	}
+
+	wantDeps := []CycloneDXDependency{
+		{Ref: "web", DependsOn: []string{"pkg:npm/%40types/node@18.0.0", "pkg:npm/express@4.18.2"}},
+		{Ref: "pkg:npm/%40types/node@18.0.0"},
+		{Ref: "pkg:npm/express@4.18.2", DependsOn: []string{"pkg:npm/qs@6.11.0"}},
+		{Ref: "pkg:npm/qs@6.11.0"},
+	}
+	if !reflect.DeepEqual(bom.Dependencies, wantDeps) {
+		t.Errorf("NewCycloneDXFromJob() dependencies got = %+v, want %+v", bom.Dependencies, wantDeps)
+	}
+
+	if len(bom.Vulnerabilities) != 1 {
+		t.Fatalf("NewCycloneDXFromJob() vulnerabilities got = %v, want 1", len(bom.Vulnerabilities))
+	}
+	vuln := bom.Vulnerabilities[0]
+	if vuln.ID != "HV00001" || vuln.Ratings[0].Severity != "high" || vuln.Recommendation != "Upgrade to 6.11.1" || vuln.Affects[0].Ref != "pkg:npm/qs@6.11.0" {
+		t.Errorf("NewCycloneDXFromJob() vulnerability got = %+v", vuln)
+	}
+}
+
+func TestCycloneDXBOM_Write(t *testing.T) {
+	bom := NewCycloneDXFromJob(testSBOMJob(), &CycloneDXOptions{SerialNumber: "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79"})
+
+	var jsonOut bytes.Buffer
+	if err := bom.WriteJSON(&jsonOut); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded CycloneDXBOM
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+		t.Fatalf("WriteJSON() wrote invalid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, bom) {
+		t.Errorf("WriteJSON() round trip differs:\n%+v\n%+v", decoded, *bom)
+	}
+
+	var xmlOut bytes.Buffer
+	if err := bom.WriteXML(&xmlOut); err != nil {
+		t.Fatalf("WriteXML() error = %v", err)
+	}
+	var doc cdxXMLBOM
+	if err := xml.Unmarshal(xmlOut.Bytes(), &doc); err != nil {
+		t.Fatalf("WriteXML() wrote invalid XML: %v", err)
+	}
+	if len(doc.Components) != 3 || len(doc.Dependencies.Dependencies) != 4 || len(doc.Vulnerabilities.Vulnerabilities) != 1 {
+		t.Errorf("WriteXML() got %v components, %+v dependencies, %+v vulnerabilities", len(doc.Components), doc.Dependencies, doc.Vulnerabilities)
+	}
+	if strings.Contains(xmlOut.String(), "<properties></properties>") {
+		t.Errorf("WriteXML() wrote an empty properties element")
+	}
+	for _, want := range []string{
+		`<bom xmlns="http://cyclonedx.org/schema/bom/1.5" serialNumber="urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79" version="1">`,
+		`<expression>BSD-3-Clause OR MIT</expression>`,
+		`<dependency ref="pkg:npm/express@4.18.2">`,
+		`<severity>high</severity>`,
+	} {
+		if !strings.Contains(xmlOut.String(), want) {
+			t.Errorf("WriteXML() output is missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package phylum
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// JobDiff is what changed between two verbose jobs of a project, such as the default branch and
+// a pull request
+type JobDiff struct {
+	BaseScore      float64
+	HeadScore      float64
+	Added          []DiffPackage
+	Removed        []DiffPackage
+	Changed        []DiffVersionChange
+	NewIssues      []DiffIssue
+	ResolvedIssues []DiffIssue
+	Domains        []DiffDomainScore // Domains whose score changed
+	Licenses       []DiffLicenseChange
+}
+
+// DiffPackage is a package added or removed between jobs
+type DiffPackage struct {
+	PackageDescriptor
+	Score   *float64
+	License string
+}
+
+// DiffVersionChange is a package whose version changed between jobs
+type DiffVersionChange struct {
+	Type      PackageType
+	Name      string
+	From      string
+	To        string
+	FromScore *float64
+	ToScore   *float64
+}
+
+// DiffIssue is an issue found in only one of the jobs
+type DiffIssue struct {
+	Package PackageDescriptor
+	Issue   Issue
+}
+
+// DiffDomainScore is the change in the lowest package score of a risk domain
+type DiffDomainScore struct {
+	Domain RiskDomain
+	Base   float64
+	Head   float64
+}
+
+// Delta is the head score minus the base score
+func (d DiffDomainScore) Delta() float64 {
+	return d.Head - d.Base
+}
+
+// DiffLicenseChange is a package whose license changed between jobs
+type DiffLicenseChange struct {
+	Type PackageType
+	Name string
+	From string
+	To   string
+}
+
+// HasChanges reports whether the jobs differ in packages, issues, domain scores or licenses
+func (d *JobDiff) HasChanges() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0 || len(d.NewIssues) > 0 ||
+		len(d.ResolvedIssues) > 0 || len(d.Domains) > 0 || len(d.Licenses) > 0
+}
+
+// DiffJobs compares the packages of two verbose jobs. Packages are matched by ecosystem and
+// name, so an upgrade is a version change rather than an add and a remove, and an issue is only
+// new when no version of the package had it in base.
+func DiffJobs(base *JobStatusResponseForPackageStatusExtended, head *JobStatusResponseForPackageStatusExtended) *JobDiff {
+	if base == nil {
+		base = &JobStatusResponseForPackageStatusExtended{}
+	}
+	if head == nil {
+		head = &JobStatusResponseForPackageStatusExtended{}
+	}
+	diff := &JobDiff{BaseScore: base.Score, HeadScore: head.Score}
+
+	baseStatus := jobPackageStatuses(base)
+	headStatus := jobPackageStatuses(head)
+	added, removed, changed := diffPackageVersions(statusDescriptors(baseStatus), statusDescriptors(headStatus))
+	for _, pkg := range added {
+		diff.Added = append(diff.Added, diffPackage(pkg, headStatus[pkg]))
+	}
+	for _, pkg := range removed {
+		diff.Removed = append(diff.Removed, diffPackage(pkg, baseStatus[pkg]))
+	}
+	for _, pair := range changed {
+		diff.Changed = append(diff.Changed, DiffVersionChange{
+			Type:      pair[1].Type,
+			Name:      pair[1].Name,
+			From:      pair[0].Version,
+			To:        pair[1].Version,
+			FromScore: baseStatus[pair[0]].PackageScore,
+			ToScore:   headStatus[pair[1]].PackageScore,
+		})
+	}
+
+	diff.NewIssues = diffIssues(headStatus, baseStatus)
+	diff.ResolvedIssues = diffIssues(baseStatus, headStatus)
+
+	baseDomains := make(map[RiskDomain]float64)
+	for _, domain := range jobDomains(base) {
+		baseDomains[domain.Domain] = domain.Score
+	}
+	for _, domain := range jobDomains(head) {
+		if score, ok := baseDomains[domain.Domain]; ok && score != domain.Score {
+			diff.Domains = append(diff.Domains, DiffDomainScore{Domain: domain.Domain, Base: score, Head: domain.Score})
+		}
+	}
+
+	baseLicenses := packageLicenses(baseStatus)
+	headLicenses := packageLicenses(headStatus)
+	for _, key := range sortedPackageKeys(headLicenses) {
+		from, ok := baseLicenses[key]
+		if to := headLicenses[key]; ok && from.license != to.license {
+			diff.Licenses = append(diff.Licenses, DiffLicenseChange{Type: key.Type, Name: to.name, From: from.license, To: to.license})
+		}
+	}
+	return diff
+}
+
+func jobPackageStatuses(job *JobStatusResponseForPackageStatusExtended) map[PackageDescriptor]PackageStatusExtended {
+	statuses := make(map[PackageDescriptor]PackageStatusExtended)
+	for _, status := range job.Packages {
+		statuses[PackageDescriptor{Name: status.Name, Version: status.Version, Type: jobPackageType(status.Type)}] = status
+	}
+	return statuses
+}
+
+func statusDescriptors(statuses map[PackageDescriptor]PackageStatusExtended) []PackageDescriptor {
+	packages := make([]PackageDescriptor, 0, len(statuses))
+	for pkg := range statuses {
+		packages = append(packages, pkg)
+	}
+	return packages
+}
+
+// packageKey identifies a package independently of its version
+type packageKey struct {
+	Type PackageType
+	Name string
+}
+
+// diffPackageVersions matches packages by ecosystem and name. Versions only in base are paired,
+// lowest first, with versions only in head as version changes; the remainder are removed or
+// added. Packages keep their spelling from the side they come from. Results are sorted by
+// ecosystem, name and version.
+func diffPackageVersions(base []PackageDescriptor, head []PackageDescriptor) (added []PackageDescriptor, removed []PackageDescriptor, changed [][2]PackageDescriptor) {
+	// Each version keeps the package as it is spelled on its own side
+	versions := func(packages []PackageDescriptor) map[packageKey]map[string]PackageDescriptor {
+		result := make(map[packageKey]map[string]PackageDescriptor)
+		for _, pkg := range packages {
+			key := diffPackageKey(pkg)
+			if result[key] == nil {
+				result[key] = make(map[string]PackageDescriptor)
+			}
+			result[key][pkg.Version] = pkg
+		}
+		return result
+	}
+	baseVersions, headVersions := versions(base), versions(head)
+
+	keys := make(map[packageKey]bool)
+	for key := range baseVersions {
+		keys[key] = true
+	}
+	for key := range headVersions {
+		keys[key] = true
+	}
+
+	for _, key := range sortedPackageKeys(keys) {
+		var onlyBase, onlyHead []string
+		for v := range baseVersions[key] {
+			if _, ok := headVersions[key][v]; !ok {
+				onlyBase = append(onlyBase, v)
+			}
+		}
+		for v := range headVersions[key] {
+			if _, ok := baseVersions[key][v]; !ok {
+				onlyHead = append(onlyHead, v)
+			}
+		}
+		sortVersions(key.Type, onlyBase)
+		sortVersions(key.Type, onlyHead)
+
+		for len(onlyBase) > 0 && len(onlyHead) > 0 {
+			changed = append(changed, [2]PackageDescriptor{baseVersions[key][onlyBase[0]], headVersions[key][onlyHead[0]]})
+			onlyBase, onlyHead = onlyBase[1:], onlyHead[1:]
+		}
+		for _, v := range onlyBase {
+			removed = append(removed, baseVersions[key][v])
+		}
+		for _, v := range onlyHead {
+			added = append(added, headVersions[key][v])
+		}
+	}
+	return added, removed, changed
+}
+
+// diffPackageKey normalizes names that their ecosystem treats as equal
+func diffPackageKey(pkg PackageDescriptor) packageKey {
+	switch pkg.Type {
+	case Pypi:
+		return packageKey{pkg.Type, normalizePythonName(pkg.Name)}
+	case Nuget:
+		return packageKey{pkg.Type, strings.ToLower(pkg.Name)}
+	}
+	return packageKey{pkg.Type, pkg.Name}
+}
+
+func sortVersions(pkgType PackageType, versions []string) {
+	sort.Slice(versions, func(i, j int) bool { return CompareEcosystemVersions(pkgType, versions[i], versions[j]) < 0 })
+}
+
+func sortedPackageKeys[V any](m map[packageKey]V) []packageKey {
+	keys := make([]packageKey, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Type != keys[j].Type {
+			return keys[i].Type < keys[j].Type
+		}
+		return keys[i].Name < keys[j].Name
+	})
+	return keys
+}
+
+func diffPackage(pkg PackageDescriptor, status PackageStatusExtended) DiffPackage {
+	return DiffPackage{PackageDescriptor: pkg, Score: status.PackageScore, License: stringValue(status.License)}
+}
+
+// diffIssues returns the issues of packages in a that no version of the package has in b
+func diffIssues(a map[PackageDescriptor]PackageStatusExtended, b map[PackageDescriptor]PackageStatusExtended) []DiffIssue {
+	type issueKey struct {
+		pkg packageKey
+		id  string
+	}
+	existing := make(map[issueKey]bool)
+	for pkg, status := range b {
+		for _, issue := range status.Issues {
+			existing[issueKey{diffPackageKey(pkg), sarifRuleID(issue)}] = true
+		}
+	}
+
+	var result []DiffIssue
+	for pkg, status := range a {
+		for _, issue := range status.Issues {
+			if !existing[issueKey{diffPackageKey(pkg), sarifRuleID(issue)}] {
+				result = append(result, DiffIssue{Package: pkg, Issue: issue})
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		si, sj := RiskLevelSeverity(result[i].Issue.Severity), RiskLevelSeverity(result[j].Issue.Severity)
+		if si != sj {
+			return si > sj
+		}
+		pi, pj := result[i].Package, result[j].Package
+		if pi != pj {
+			return pi.Type < pj.Type || (pi.Type == pj.Type && (pi.Name < pj.Name || (pi.Name == pj.Name && pi.Version < pj.Version)))
+		}
+		return sarifRuleID(result[i].Issue) < sarifRuleID(result[j].Issue)
+	})
+	return result
+}
+
+// packageLicense is the license of every version of a package
+type packageLicense struct {
+	name    string
+	license string
+}
+
+// packageLicenses returns the license of each package, skipping packages whose versions
+// disagree as their license change is ambiguous
+func packageLicenses(statuses map[PackageDescriptor]PackageStatusExtended) map[packageKey]packageLicense {
+	licenses := make(map[packageKey]packageLicense)
+	ambiguous := make(map[packageKey]bool)
+	for pkg, status := range statuses {
+		key := diffPackageKey(pkg)
+		license := strings.TrimSpace(stringValue(status.License))
+		if existing, ok := licenses[key]; ok && existing.license != license {
+			ambiguous[key] = true
+		}
+		licenses[key] = packageLicense{name: pkg.Name, license: license}
+	}
+	for key := range ambiguous {
+		delete(licenses, key)
+	}
+	return licenses
+}
+
+var diffMarkdown = texttemplate.Must(texttemplate.New("diff.md").Funcs(ReportFuncs).Funcs(map[string]interface{}{
+	"delta": func(from float64, to float64) string {
+		return fmt.Sprintf("%+.0f", (to-from)*100)
+	},
+}).Parse(`## Phylum dependency changes
+
+Score: {{score .BaseScore}} → {{score .HeadScore}} ({{delta .BaseScore .HeadScore}})
+{{if not .HasChanges}}
+No dependency changes.
+{{end}}{{if .Added}}
+### Added packages
+
+| Package | Version | Ecosystem | Score | License |
+| --- | --- | --- | --- | --- |
+{{range .Added}}| {{cell .Name}} | {{cell .Version}} | {{.Type}} | {{score .Score}} | {{cell .License}} |
+{{end}}{{end}}{{if .Removed}}
+### Removed packages
+
+| Package | Version | Ecosystem |
+| --- | --- | --- |
+{{range .Removed}}| {{cell .Name}} | {{cell .Version}} | {{.Type}} |
+{{end}}{{end}}{{if .Changed}}
+### Changed versions
+
+| Package | Ecosystem | From | To | Score |
+| --- | --- | --- | --- | --- |
+{{range .Changed}}| {{cell .Name}} | {{.Type}} | {{cell .From}} | {{cell .To}} | {{score .FromScore}} → {{score .ToScore}} |
+{{end}}{{end}}{{if .NewIssues}}
+### New issues
+
+{{range .NewIssues}}- **[{{upper .Issue.Severity}}]** {{.Issue.Title}} in ` + "`{{.Package.Name}}@{{.Package.Version}}`" + `
+{{end}}{{end}}{{if .ResolvedIssues}}
+### Resolved issues
+
+{{range .ResolvedIssues}}- ~~[{{upper .Issue.Severity}}] {{.Issue.Title}}~~ in ` + "`{{.Package.Name}}@{{.Package.Version}}`" + `
+{{end}}{{end}}{{if .Domains}}
+### Risk domains
+
+| Domain | Base | Head | Change |
+| --- | --- | --- | --- |
+{{range .Domains}}| {{.Domain}} | {{score .Base}} | {{score .Head}} | {{delta .Base .Head}} |
+{{end}}{{end}}{{if .Licenses}}
+### License changes
+
+| Package | Ecosystem | From | To |
+| --- | --- | --- | --- |
+{{range .Licenses}}| {{cell .Name}} | {{.Type}} | {{cell .From}} | {{cell .To}} |
+{{end}}{{end}}`))
+
+// WriteMarkdown renders the diff as Markdown suitable for a pull request comment
+func (d *JobDiff) WriteMarkdown(w io.Writer) error {
+	if err := diffMarkdown.Execute(w, d); err != nil {
+		return fmt.Errorf("WriteMarkdown(): %w", err)
+	}
+	return nil
+}
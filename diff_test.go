@@ -0,0 +1,156 @@
+package phylum
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testDiffJobs() (*JobStatusResponseForPackageStatusExtended, *JobStatusResponseForPackageStatusExtended) {
+	str := func(s string) *string { return &s }
+	score := func(f float64) *float64 { return &f }
+	vectors := func(vulnerability float64) PackageStatusExtended_RiskVectors {
+		return PackageStatusExtended_RiskVectors{AdditionalProperties: map[string]float64{"vulnerability": vulnerability, "author": 0.9}}
+	}
+	pollution := Issue{Title: "Prototype pollution", Tag: str("HV00001"), Severity: High, Domain: RiskDomainVulnerability}
+	typosquat := Issue{Title: "Typosquat", Tag: str("HM00002"), Severity: Critical, Domain: RiskDomainMaliciousCode}
+
+	base := &JobStatusResponseForPackageStatusExtended{
+		Score: 0.6,
+		Packages: []PackageStatusExtended{
+			{Name: "express", Version: "4.18.2", Type: "npm", License: str("MIT"), PackageScore: score(0.9), RiskVectors: vectors(0.9)},
+			{Name: "qs", Version: "6.11.0", Type: "npm", License: str("BSD-3-Clause"), PackageScore: score(0.3), RiskVectors: vectors(0.3), Issues: []Issue{pollution}},
+			{Name: "left-pad", Version: "1.3.0", Type: "npm", License: str("WTFPL"), PackageScore: score(0.8), RiskVectors: vectors(0.8)},
+		},
+	}
+	head := &JobStatusResponseForPackageStatusExtended{
+		Score: 0.4,
+		Packages: []PackageStatusExtended{
+			{Name: "express", Version: "4.18.2", Type: "npm", License: str("MIT"), PackageScore: score(0.9), RiskVectors: vectors(0.9)},
+			{Name: "qs", Version: "6.11.1", Type: "npm", License: str("BSD-3-Clause OR MIT"), PackageScore: score(0.9), RiskVectors: vectors(0.9)},
+			{Name: "expres", Version: "1.0.0", Type: "npm", PackageScore: score(0.1), RiskVectors: vectors(0.7), Issues: []Issue{typosquat}},
+		},
+	}
+	return base, head
+}
+
+func TestDiffJobs(t *testing.T) {
+	score := func(f float64) *float64 { return &f }
+	diff := DiffJobs(testDiffJobs())
+
+	wantAdded := []DiffPackage{{PackageDescriptor: PackageDescriptor{Name: "expres", Version: "1.0.0", Type: "npm"}, Score: score(0.1)}}
+	if !reflect.DeepEqual(diff.Added, wantAdded) {
+		t.Errorf("DiffJobs() Added got = %+v, want %+v", diff.Added, wantAdded)
+	}
+	wantRemoved := []DiffPackage{{PackageDescriptor: PackageDescriptor{Name: "left-pad", Version: "1.3.0", Type: "npm"}, Score: score(0.8), License: "WTFPL"}}
+	if !reflect.DeepEqual(diff.Removed, wantRemoved) {
+		t.Errorf("DiffJobs() Removed got = %+v, want %+v", diff.Removed, wantRemoved)
+	}
+	wantChanged := []DiffVersionChange{{Type: "npm", Name: "qs", From: "6.11.0", To: "6.11.1", FromScore: score(0.3), ToScore: score(0.9)}}
+	if !reflect.DeepEqual(diff.Changed, wantChanged) {
+		t.Errorf("DiffJobs() Changed got = %+v, want %+v", diff.Changed, wantChanged)
+	}
+
+	if len(diff.NewIssues) != 1 || diff.NewIssues[0].Issue.Title != "Typosquat" || diff.NewIssues[0].Package.Name != "expres" {
+		t.Errorf("DiffJobs() NewIssues got = %+v", diff.NewIssues)
+	}
+	if len(diff.ResolvedIssues) != 1 || diff.ResolvedIssues[0].Issue.Title != "Prototype pollution" {
+		t.Errorf("DiffJobs() ResolvedIssues got = %+v", diff.ResolvedIssues)
+	}
+
+	wantDomains := []DiffDomainScore{{Domain: RiskDomainVulnerability, Base: 0.3, Head: 0.7}}
+	if !reflect.DeepEqual(diff.Domains, wantDomains) {
+		t.Errorf("DiffJobs() Domains got = %+v, want %+v", diff.Domains, wantDomains)
+	}
+	wantLicenses := []DiffLicenseChange{{Type: "npm", Name: "qs", From: "BSD-3-Clause", To: "BSD-3-Clause OR MIT"}}
+	if !reflect.DeepEqual(diff.Licenses, wantLicenses) {
+		t.Errorf("DiffJobs() Licenses got = %+v, want %+v", diff.Licenses, wantLicenses)
+	}
+}
+
+func TestDiffJobsNormalizedNames(t *testing.T) {
+	str := func(s string) *string { return &s }
+	score := func(f float64) *float64 { return &f }
+	sqlInjection := Issue{Title: "SQL injection", Tag: str("HV00003"), Severity: High, Domain: RiskDomainVulnerability}
+
+	base := &JobStatusResponseForPackageStatusExtended{Packages: []PackageStatusExtended{
+		{Name: "Django", Version: "3.2.0", Type: "pypi", License: str("BSD-3-Clause"), PackageScore: score(0.4), Issues: []Issue{sqlInjection}},
+		{Name: "Newtonsoft.Json", Version: "13.0.1", Type: "nuget", License: str("MIT"), PackageScore: score(0.9)},
+	}}
+	head := &JobStatusResponseForPackageStatusExtended{Packages: []PackageStatusExtended{
+		{Name: "django", Version: "3.2.1", Type: "pypi", License: str("BSD-3-Clause"), PackageScore: score(0.5), Issues: []Issue{sqlInjection}},
+		{Name: "newtonsoft.json", Version: "13.0.1", Type: "nuget", License: str("MIT"), PackageScore: score(0.9)},
+	}}
+	diff := DiffJobs(base, head)
+
+	wantChanged := []DiffVersionChange{{Type: "pypi", Name: "django", From: "3.2.0", To: "3.2.1", FromScore: score(0.4), ToScore: score(0.5)}}
+	if !reflect.DeepEqual(diff.Changed, wantChanged) {
+		t.Errorf("DiffJobs() Changed got = %+v, want %+v", diff.Changed, wantChanged)
+	}
+	if len(diff.Added) != 0 || len(diff.Removed) != 0 || len(diff.NewIssues) != 0 || len(diff.ResolvedIssues) != 0 || len(diff.Licenses) != 0 {
+		t.Errorf("DiffJobs() got = %+v, want only the version change", diff)
+	}
+}
+
+func TestDiffJobsUnchanged(t *testing.T) {
+	base, _ := testDiffJobs()
+	diff := DiffJobs(base, base)
+	if diff.HasChanges() {
+		t.Errorf("DiffJobs() of identical jobs got = %+v", diff)
+	}
+
+	var buf bytes.Buffer
+	if err := diff.WriteMarkdown(&buf); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "No dependency changes.") {
+		t.Errorf("WriteMarkdown() got = %v", buf.String())
+	}
+}
+
+func TestDiffPackageVersions(t *testing.T) {
+	base := []PackageDescriptor{
+		{Name: "lodash", Version: "4.17.20", Type: "npm"},
+		{Name: "lodash", Version: "3.10.1", Type: "npm"},
+		{Name: "debug", Version: "2.6.9", Type: "npm"},
+	}
+	head := []PackageDescriptor{
+		{Name: "lodash", Version: "4.17.21", Type: "npm"},
+		{Name: "debug", Version: "2.6.9", Type: "npm"},
+		{Name: "debug", Version: "4.3.4", Type: "npm"},
+	}
+	added, removed, changed := diffPackageVersions(base, head)
+	if want := []PackageDescriptor{{Name: "debug", Version: "4.3.4", Type: "npm"}}; !reflect.DeepEqual(added, want) {
+		t.Errorf("diffPackageVersions() added got = %v, want %v", added, want)
+	}
+	if want := []PackageDescriptor{{Name: "lodash", Version: "4.17.20", Type: "npm"}}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("diffPackageVersions() removed got = %v, want %v", removed, want)
+	}
+	wantChanged := [][2]PackageDescriptor{{{Name: "lodash", Version: "3.10.1", Type: "npm"}, {Name: "lodash", Version: "4.17.21", Type: "npm"}}}
+	if !reflect.DeepEqual(changed, wantChanged) {
+		t.Errorf("diffPackageVersions() changed got = %v, want %v", changed, wantChanged)
+	}
+}
+
+func TestJobDiffWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := DiffJobs(testDiffJobs()).WriteMarkdown(&buf); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Score: 60 → 40 (-20)",
+		"| expres | 1.0.0 | npm | 10 |  |",
+		"| left-pad | 1.3.0 | npm |",
+		"| qs | npm | 6.11.0 | 6.11.1 | 30 → 90 |",
+		"- **[CRITICAL]** Typosquat in `expres@1.0.0`",
+		"- ~~[HIGH] Prototype pollution~~ in `qs@6.11.0`",
+		"| vulnerability | 30 | 70 | +40 |",
+		"| qs | npm | BSD-3-Clause | BSD-3-Clause OR MIT |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteMarkdown() missing %q in:\n%s", want, out)
+		}
+	}
+}
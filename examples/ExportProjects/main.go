@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/peterjmorgan/go-phylum"
+)
+
+func main() {
+	p, err := phylum.NewClient(&phylum.ClientOptions{})
+	if err != nil {
+		fmt.Printf("Failed to create phylum client: %v\n", err)
+		return
+	}
+
+	var files []*os.File
+	for _, name := range []string{"projects.csv", "dependencies.csv", "issues.csv"} {
+		f, err := os.Create(name)
+		if err != nil {
+			fmt.Printf("Failed to create %v: %v\n", name, err)
+			return
+		}
+		defer f.Close()
+		files = append(files, f)
+	}
+
+	exporter, err := phylum.NewProjectExporter(&phylum.ExportOptions{
+		Format:       phylum.ExportCSV,
+		Projects:     files[0],
+		Dependencies: files[1],
+		Issues:       files[2],
+	})
+	if err != nil {
+		fmt.Printf("Failed to create exporter: %v\n", err)
+		return
+	}
+
+	if err := p.ExportAllProjects(exporter); err != nil {
+		fmt.Printf("Failed to ExportAllProjects(): %v\n", err)
+	}
+	if err := exporter.Close(); err != nil {
+		fmt.Printf("Failed to finish export: %v\n", err)
+	}
+}
@@ -0,0 +1,306 @@
+package phylum
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormat is the file format written by a ProjectExporter
+type ExportFormat string
+
+const (
+	ExportCSV  ExportFormat = "csv"
+	ExportXLSX ExportFormat = "xlsx"
+)
+
+// ExportOptions are the format and destinations of the project, dependency and issue tables.
+// A table whose writer is nil is not exported.
+type ExportOptions struct {
+	Format       ExportFormat // Defaults to ExportCSV
+	Projects     io.Writer
+	Dependencies io.Writer
+	Issues       io.Writer
+}
+
+// exportColumn is a column of an exported table
+type exportColumn struct {
+	name    string
+	numeric bool
+}
+
+// Column orders are part of the export format: add new columns at the end
+var (
+	projectExportColumns = []exportColumn{
+		{"project_id", false}, {"name", false}, {"label", false}, {"registry", false},
+		{"created_at", false}, {"updated_at", false}, {"latest_job_created_at", false},
+		{"score_total", true}, {"score_author", true}, {"score_engineering", true}, {"score_license", true},
+		{"score_malicious_code", true}, {"score_vulnerability", true},
+		{"dependencies", true}, {"issues", true},
+		{"critical", true}, {"high", true}, {"medium", true}, {"low", true},
+	}
+	dependencyExportColumns = []exportColumn{
+		{"project_id", false}, {"name", false}, {"version", false}, {"registry", false}, {"purl", false},
+		{"license", false}, {"license_category", false}, {"complete", false},
+		{"score_total", true}, {"score_author", true}, {"score_engineering", true}, {"score_license", true},
+		{"score_malicious_code", true}, {"score_vulnerability", true},
+		{"issues", true}, {"repo_url", false}, {"published_date", false},
+	}
+	issueExportColumns = []exportColumn{
+		{"project_id", false}, {"package", false}, {"version", false}, {"registry", false},
+		{"issue_id", false}, {"tag", false}, {"title", false}, {"severity", false}, {"risk_type", false},
+		{"score", true}, {"ignored", false}, {"cves", false}, {"fixed_in", false},
+	}
+)
+
+// tableWriter is implemented by csv.Writer wrappers and xlsxWriter
+type tableWriter interface {
+	Write(record []string) error
+	Close() error
+}
+
+type csvTableWriter struct {
+	*csv.Writer
+}
+
+func (c csvTableWriter) Write(record []string) error {
+	if err := c.Writer.Write(record); err != nil {
+		return err
+	}
+	// Flushing per row keeps memory flat however many projects are exported
+	c.Writer.Flush()
+	return c.Writer.Error()
+}
+
+func (c csvTableWriter) Close() error {
+	c.Writer.Flush()
+	return c.Writer.Error()
+}
+
+// ProjectExporter writes projects as flat tables of projects, dependencies and issues, keyed by
+// project ID. Rows are written as each project is passed to Write, so exports of any size use
+// constant memory. CSV output follows RFC 4180; XLSX output is one workbook per table.
+type ProjectExporter struct {
+	projects     tableWriter
+	dependencies tableWriter
+	issues       tableWriter
+}
+
+// NewProjectExporter writes the header row of each table
+func NewProjectExporter(opts *ExportOptions) (*ProjectExporter, error) {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+	format := opts.Format
+	if format == "" {
+		format = ExportCSV
+	}
+	if format != ExportCSV && format != ExportXLSX {
+		return nil, fmt.Errorf("NewProjectExporter(): unsupported format %q", format)
+	}
+
+	e := &ProjectExporter{}
+	tables := []struct {
+		writer  io.Writer
+		name    string
+		columns []exportColumn
+		dest    *tableWriter
+	}{
+		{opts.Projects, "projects", projectExportColumns, &e.projects},
+		{opts.Dependencies, "dependencies", dependencyExportColumns, &e.dependencies},
+		{opts.Issues, "issues", issueExportColumns, &e.issues},
+	}
+	for _, table := range tables {
+		if table.writer == nil {
+			continue
+		}
+		var header []string
+		var numeric []bool
+		for _, column := range table.columns {
+			header = append(header, column.name)
+			numeric = append(numeric, column.numeric)
+		}
+
+		var tw tableWriter
+		if format == ExportXLSX {
+			xw, err := newXLSXWriter(table.writer, table.name, numeric)
+			if err != nil {
+				return nil, fmt.Errorf("NewProjectExporter(): %v: %w", table.name, err)
+			}
+			tw = xw
+		} else {
+			cw := csv.NewWriter(table.writer)
+			cw.UseCRLF = true
+			tw = csvTableWriter{cw}
+		}
+		if err := tw.Write(header); err != nil {
+			return nil, fmt.Errorf("NewProjectExporter(): %v: %w", table.name, err)
+		}
+		*table.dest = tw
+	}
+	return e, nil
+}
+
+// Write appends the rows for one project to each table
+func (e *ProjectExporter) Write(project *ProjectResponse) error {
+	if project == nil {
+		return nil
+	}
+	projectID := project.Id.String()
+
+	if e.projects != nil {
+		if err := e.projects.Write(projectExportRow(project)); err != nil {
+			return fmt.Errorf("Write(): projects: %w", err)
+		}
+	}
+	for _, dep := range project.Dependencies {
+		if e.dependencies != nil {
+			if err := e.dependencies.Write(dependencyExportRow(projectID, dep)); err != nil {
+				return fmt.Errorf("Write(): dependencies: %w", err)
+			}
+		}
+		if e.issues == nil {
+			continue
+		}
+		for _, issue := range dep.Issues {
+			if err := e.issues.Write(issueExportRow(projectID, dep, issue)); err != nil {
+				return fmt.Errorf("Write(): issues: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Close flushes each table and, for XLSX, finishes the workbooks. The underlying writers are
+// not closed.
+func (e *ProjectExporter) Close() error {
+	for _, tw := range []tableWriter{e.projects, e.dependencies, e.issues} {
+		if tw == nil {
+			continue
+		}
+		if err := tw.Close(); err != nil {
+			return fmt.Errorf("Close(): %w", err)
+		}
+	}
+	return nil
+}
+
+// ExportAllProjects fetches every user and group project one at a time and writes it to e, so
+// only one project is held in memory
+func (p *PhylumClient) ExportAllProjects(e *ProjectExporter) error {
+	projects, err := p.ListAllProjects()
+	if err != nil {
+		return fmt.Errorf("ExportAllProjects(): %w", err)
+	}
+	for _, summary := range projects {
+		var project *ProjectResponse
+		if summary.GroupName != nil {
+			project, err = p.GetGroupProject(*summary.GroupName, summary.Id.String())
+		} else {
+			project, err = p.GetUserProject(summary.Id.String())
+		}
+		if err != nil {
+			return fmt.Errorf("ExportAllProjects(): %v: %w", summary.Id, err)
+		}
+		if err := e.Write(project); err != nil {
+			return fmt.Errorf("ExportAllProjects(): %w", err)
+		}
+	}
+	return nil
+}
+
+func projectExportRow(project *ProjectResponse) []string {
+	scores := project.RiskScores
+	return []string{
+		project.Id.String(),
+		project.Name,
+		stringValue(project.Label),
+		stringValue(project.Registry),
+		exportTime(&project.CreatedAt),
+		exportTime(project.UpdatedAt),
+		exportTime(project.LatestJobCreatedAt),
+		exportScore(scores.Total),
+		exportScore(scores.Author),
+		exportScore(scores.Engineering),
+		exportScore(scores.License),
+		exportScore(scores.MaliciousCode),
+		exportScore(scores.Vulnerability),
+		strconv.Itoa(len(project.Dependencies)),
+		strconv.Itoa(len(project.Issues)),
+		exportCount(project.IssueImpacts.Critical),
+		exportCount(project.IssueImpacts.High),
+		exportCount(project.IssueImpacts.Medium),
+		exportCount(project.IssueImpacts.Low),
+	}
+}
+
+func dependencyExportRow(projectID string, dep FullPackageInternal) []string {
+	pkg := PackageDescriptor{Name: dep.Name, Version: dep.Version, Type: PackageType(dep.Registry)}
+	scores := dep.RiskScores
+	return []string{
+		projectID,
+		dep.Name,
+		dep.Version,
+		dep.Registry,
+		PackageURL(pkg),
+		stringValue(dep.License),
+		string(CheckPackageLicense(pkg, dep.License, nil).Category),
+		strconv.FormatBool(dep.Complete),
+		exportScore(scores.Total),
+		exportScore(scores.Author),
+		exportScore(scores.Engineering),
+		exportScore(scores.License),
+		exportScore(scores.MaliciousCode),
+		exportScore(scores.Vulnerability),
+		strconv.Itoa(len(dep.Issues)),
+		stringValue(dep.RepoUrl),
+		dep.PublishedDate,
+	}
+}
+
+func issueExportRow(projectID string, dep FullPackageInternal, issue IssuesListItem) []string {
+	remediation := ParseRemediation(issue.Description)
+	return []string{
+		projectID,
+		dep.Name,
+		dep.Version,
+		dep.Registry,
+		stringValue(issue.Id),
+		stringValue(issue.Tag),
+		issue.Title,
+		string(issue.Impact),
+		string(issue.RiskType),
+		exportScore(issue.Score),
+		string(issue.Ignored),
+		strings.Join(remediation.CVEs, " "),
+		remediation.FixedIn,
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func exportTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func exportScore(score float32) string {
+	return strconv.FormatFloat(float64(score), 'f', -1, 32)
+}
+
+func exportCount(count *uint32) string {
+	if count == nil {
+		return "0"
+	}
+	return strconv.FormatUint(uint64(*count), 10)
+}
@@ -0,0 +1,163 @@
+package phylum
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	openapi_types "github.com/deepmap/oapi-codegen/pkg/types"
+)
+
+func testExportProject() *ProjectResponse {
+	str := func(s string) *string { return &s }
+	high := uint32(1)
+	updated := time.Date(2023, 5, 2, 10, 0, 0, 0, time.UTC)
+	project := &ProjectResponse{
+		Id:        openapi_types.UUID{0x12, 0x34},
+		Name:      "web, frontend",
+		Label:     str("main"),
+		CreatedAt: time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC),
+		UpdatedAt: &updated,
+		Issues:    []IssuesListItem{{Title: "Prototype pollution"}},
+		Dependencies: []FullPackageInternal{
+			{Name: "express", Version: "4.18.2", Registry: "npm", License: str("MIT"), Complete: true},
+			{
+				Name: "qs", Version: "6.11.0", Registry: "npm", License: str("BSD-3-Clause"), Complete: true,
+				Issues: []IssuesListItem{{
+					Title:       `Prototype "pollution"`,
+					Tag:         str("HV00001"),
+					Impact:      High,
+					RiskType:    Vulnerabilities,
+					Score:       0.3,
+					Description: "CVE-2022-24999\n\n### Recommendation\nUpgrade to 6.11.1",
+				}},
+			},
+		},
+	}
+	project.RiskScores.Total = 0.3
+	project.RiskScores.Vulnerability = 0.3
+	project.IssueImpacts.High = &high
+	return project
+}
+
+func TestProjectExporterCSV(t *testing.T) {
+	var projects, dependencies, issues bytes.Buffer
+	e, err := NewProjectExporter(&ExportOptions{Projects: &projects, Dependencies: &dependencies, Issues: &issues})
+	if err != nil {
+		t.Fatalf("NewProjectExporter() error = %v", err)
+	}
+	if err := e.Write(testExportProject()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if !strings.Contains(projects.String(), "\r\n") {
+		t.Errorf("CSV rows not terminated with CRLF")
+	}
+	if !strings.Contains(projects.String(), `,"web, frontend",`) {
+		t.Errorf("CSV field with a comma not quoted: %s", projects.String())
+	}
+
+	projectID := "12340000-0000-0000-0000-000000000000"
+	wantProjects := [][]string{
+		{"project_id", "name", "label", "registry", "created_at", "updated_at", "latest_job_created_at", "score_total", "score_author", "score_engineering", "score_license", "score_malicious_code", "score_vulnerability", "dependencies", "issues", "critical", "high", "medium", "low"},
+		{projectID, "web, frontend", "main", "", "2023-05-01T10:00:00Z", "2023-05-02T10:00:00Z", "", "0.3", "0", "0", "0", "0", "0.3", "2", "1", "0", "1", "0", "0"},
+	}
+	wantDependencies := [][]string{
+		{"project_id", "name", "version", "registry", "purl", "license", "license_category", "complete", "score_total", "score_author", "score_engineering", "score_license", "score_malicious_code", "score_vulnerability", "issues", "repo_url", "published_date"},
+		{projectID, "express", "4.18.2", "npm", "pkg:npm/express@4.18.2", "MIT", "permissive", "true", "0", "0", "0", "0", "0", "0", "0", "", ""},
+		{projectID, "qs", "6.11.0", "npm", "pkg:npm/qs@6.11.0", "BSD-3-Clause", "permissive", "true", "0", "0", "0", "0", "0", "0", "1", "", ""},
+	}
+	wantIssues := [][]string{
+		{"project_id", "package", "version", "registry", "issue_id", "tag", "title", "severity", "risk_type", "score", "ignored", "cves", "fixed_in"},
+		{projectID, "qs", "6.11.0", "npm", "", "HV00001", `Prototype "pollution"`, "high", "vulnerabilities", "0.3", "", "CVE-2022-24999", "6.11.1"},
+	}
+	for name, tt := range map[string]struct {
+		buf  *bytes.Buffer
+		want [][]string
+	}{
+		"projects":     {&projects, wantProjects},
+		"dependencies": {&dependencies, wantDependencies},
+		"issues":       {&issues, wantIssues},
+	} {
+		got, err := csv.NewReader(tt.buf).ReadAll()
+		if err != nil {
+			t.Fatalf("%v: reading CSV error = %v", name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got = %q, want %q", name, got, tt.want)
+		}
+	}
+}
+
+func TestProjectExporterXLSX(t *testing.T) {
+	var issues bytes.Buffer
+	e, err := NewProjectExporter(&ExportOptions{Format: ExportXLSX, Issues: &issues})
+	if err != nil {
+		t.Fatalf("NewProjectExporter() error = %v", err)
+	}
+	if err := e.Write(testExportProject()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(issues.Bytes()), int64(issues.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	var names []string
+	var sheet []byte
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open(%v) error = %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if err := xml.Unmarshal(data, new(struct{})); err != nil {
+			t.Errorf("%v is not well-formed XML: %v", f.Name, err)
+		}
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			sheet = data
+		}
+	}
+	wantNames := []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("XLSX parts got = %v, want %v", names, wantNames)
+	}
+
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr"><is><t xml:space="preserve">project_id</t></is></c>`,
+		`<c r="G2" t="inlineStr"><is><t xml:space="preserve">Prototype &#34;pollution&#34;</t></is></c>`,
+		`<c r="J2"><v>0.3</v></c>`,
+	} {
+		if !bytes.Contains(sheet, []byte(want)) {
+			t.Errorf("sheet missing %s in:\n%s", want, sheet)
+		}
+	}
+}
+
+func TestNewProjectExporterFormat(t *testing.T) {
+	if _, err := NewProjectExporter(&ExportOptions{Format: "ods"}); err == nil {
+		t.Errorf("NewProjectExporter() error = nil, want unsupported format")
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(i); got != want {
+			t.Errorf("xlsxColumn(%v) got = %v, want %v", i, got, want)
+		}
+	}
+}
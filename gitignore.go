@@ -0,0 +1,175 @@
+package phylum
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ignorePattern is one compiled line of a .gitignore file
+type ignorePattern struct {
+	base    string // Slash separated directory of the .gitignore, relative to the scan root
+	regex   *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreMatcher holds the .gitignore patterns that apply to a directory
+type ignoreMatcher struct {
+	patterns []ignorePattern
+}
+
+// withPatterns returns a matcher extended with the lines of a .gitignore located in base
+func (m *ignoreMatcher) withPatterns(base string, lines []string) *ignoreMatcher {
+	next := &ignoreMatcher{}
+	if m != nil {
+		next.patterns = append(next.patterns, m.patterns...)
+	}
+	for _, line := range lines {
+		if pattern, ok := compileIgnorePattern(base, line); ok {
+			next.patterns = append(next.patterns, pattern)
+		}
+	}
+	return next
+}
+
+// withFile returns a matcher extended with the .gitignore file at gitignorePath, if it exists
+func (m *ignoreMatcher) withFile(base string, gitignorePath string) (*ignoreMatcher, error) {
+	file, err := os.Open(gitignorePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return m.withPatterns(base, lines), nil
+}
+
+// ignored reports whether the slash separated path relative to the scan root is ignored. As in
+// git, the last matching pattern wins and a negated pattern re-includes the path.
+func (m *ignoreMatcher) ignored(relPath string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	ignored := false
+	for _, pattern := range m.patterns {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+		rel := relPath
+		if pattern.base != "" {
+			if !strings.HasPrefix(relPath, pattern.base+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(relPath, pattern.base+"/")
+		}
+		if pattern.regex.MatchString(rel) {
+			ignored = !pattern.negate
+		}
+	}
+	return ignored
+}
+
+// compileIgnorePattern converts a .gitignore line into a regular expression over paths relative
+// to base. Patterns without a slash match a name at any depth; patterns with one are anchored.
+func compileIgnorePattern(base string, line string) (ignorePattern, bool) {
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+
+	pattern := ignorePattern{base: base}
+	if strings.HasPrefix(line, "!") {
+		pattern.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+	expr.WriteString(globToRegexp(line))
+	expr.WriteString("$")
+
+	regex, err := regexp.Compile(expr.String())
+	if err != nil {
+		return ignorePattern{}, false
+	}
+	pattern.regex = regex
+	return pattern, true
+}
+
+// globToRegexp translates a gitignore style glob, where ** spans directories, into a regular expression
+func globToRegexp(glob string) string {
+	var expr strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && strings.HasPrefix(glob[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case c == '*' && strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			expr.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return expr.String()
+}
+
+// joinSlash joins a slash separated relative directory and a name
+func joinSlash(dir string, name string) string {
+	if dir == "" {
+		return name
+	}
+	return path.Join(dir, name)
+}
@@ -0,0 +1,405 @@
+package phylum
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// DefaultGraphPathLimit is the number of paths returned by DependencyGraph.Paths when no limit is given
+const DefaultGraphPathLimit = 10
+
+// DependencyGraph holds packages and the dependency edges between them. Packages that the
+// project depends on itself are direct; every other package is transitive.
+type DependencyGraph struct {
+	packages []PackageDescriptor
+	direct   map[PackageDescriptor]bool
+	children map[PackageDescriptor][]PackageDescriptor
+	parents  map[PackageDescriptor][]PackageDescriptor
+	byName   map[string][]PackageDescriptor
+}
+
+// NewDependencyGraph returns an empty graph
+func NewDependencyGraph() *DependencyGraph {
+	return &DependencyGraph{
+		direct:   make(map[PackageDescriptor]bool),
+		children: make(map[PackageDescriptor][]PackageDescriptor),
+		parents:  make(map[PackageDescriptor][]PackageDescriptor),
+		byName:   make(map[string][]PackageDescriptor),
+	}
+}
+
+// AddPackage adds a package to the graph. Adding a package that is already present only
+// upgrades it to direct when direct is set.
+func (g *DependencyGraph) AddPackage(pkg PackageDescriptor, direct bool) {
+	if _, ok := g.direct[pkg]; !ok {
+		g.packages = append(g.packages, pkg)
+		g.byName[pkg.Name] = append(g.byName[pkg.Name], pkg)
+		g.direct[pkg] = false
+	}
+	if direct {
+		g.direct[pkg] = true
+	}
+}
+
+// AddEdge records that parent depends on child, adding either package if missing
+func (g *DependencyGraph) AddEdge(parent PackageDescriptor, child PackageDescriptor) {
+	g.AddPackage(parent, false)
+	g.AddPackage(child, false)
+	for _, existing := range g.children[parent] {
+		if existing == child {
+			return
+		}
+	}
+	g.children[parent] = append(g.children[parent], child)
+	g.parents[child] = append(g.parents[child], parent)
+}
+
+// Packages returns every package in the order it was added
+func (g *DependencyGraph) Packages() []PackageDescriptor {
+	return append([]PackageDescriptor(nil), g.packages...)
+}
+
+// Has reports whether pkg is in the graph
+func (g *DependencyGraph) Has(pkg PackageDescriptor) bool {
+	_, ok := g.direct[pkg]
+	return ok
+}
+
+// IsDirect reports whether pkg is a direct dependency of the project
+func (g *DependencyGraph) IsDirect(pkg PackageDescriptor) bool {
+	return g.direct[pkg]
+}
+
+// DirectPackages returns the direct dependencies of the project
+func (g *DependencyGraph) DirectPackages() []PackageDescriptor {
+	return g.filter(func(pkg PackageDescriptor) bool { return g.direct[pkg] })
+}
+
+// TransitivePackages returns the packages that are only present as dependencies of other packages
+func (g *DependencyGraph) TransitivePackages() []PackageDescriptor {
+	return g.filter(func(pkg PackageDescriptor) bool { return !g.direct[pkg] })
+}
+
+// Find returns every version of the named package in the graph
+func (g *DependencyGraph) Find(name string) []PackageDescriptor {
+	return append([]PackageDescriptor(nil), g.byName[name]...)
+}
+
+// Dependencies returns the packages pkg depends on directly
+func (g *DependencyGraph) Dependencies(pkg PackageDescriptor) []PackageDescriptor {
+	return append([]PackageDescriptor(nil), g.children[pkg]...)
+}
+
+// Dependents returns the packages that depend directly on pkg
+func (g *DependencyGraph) Dependents(pkg PackageDescriptor) []PackageDescriptor {
+	return append([]PackageDescriptor(nil), g.parents[pkg]...)
+}
+
+// Paths answers "why is pkg here?" by returning up to limit dependency chains, shortest first,
+// each starting at a direct dependency and ending at pkg. A limit of 0 uses DefaultGraphPathLimit.
+// Chains never visit a package twice, and only the limit shortest chains through each package
+// are extended, so large graphs don't enumerate every path.
+func (g *DependencyGraph) Paths(pkg PackageDescriptor, limit int) [][]PackageDescriptor {
+	if !g.Has(pkg) {
+		return nil
+	}
+	if limit <= 0 {
+		limit = DefaultGraphPathLimit
+	}
+
+	// Only packages below a direct dependency can lead to one
+	underDirect := g.reachable(g.children, g.DirectPackages()...)
+
+	// Breadth first search up the parent edges so shorter chains are found first. Each queued
+	// chain is stored leaf first.
+	var result [][]PackageDescriptor
+	extended := make(map[PackageDescriptor]int)
+	queue := [][]PackageDescriptor{{pkg}}
+	for len(queue) > 0 && len(result) < limit {
+		chain := queue[0]
+		queue = queue[1:]
+		head := chain[len(chain)-1]
+		if extended[head] >= limit {
+			continue
+		}
+		extended[head]++
+
+		if g.direct[head] {
+			path := make([]PackageDescriptor, len(chain))
+			for i, p := range chain {
+				path[len(chain)-1-i] = p
+			}
+			result = append(result, path)
+			continue
+		}
+
+		for _, parent := range g.parents[head] {
+			if !underDirect[parent] || chainContains(chain, parent) {
+				continue
+			}
+			next := make([]PackageDescriptor, len(chain), len(chain)+1)
+			copy(next, chain)
+			queue = append(queue, append(next, parent))
+		}
+	}
+	return result
+}
+
+// IntroducedBy returns the direct dependencies through which pkg ends up in the project. A direct
+// package is reported as introducing itself.
+func (g *DependencyGraph) IntroducedBy(pkg PackageDescriptor) []PackageDescriptor {
+	if !g.Has(pkg) {
+		return nil
+	}
+
+	visited := map[PackageDescriptor]bool{pkg: true}
+	queue := []PackageDescriptor{pkg}
+	found := make(map[PackageDescriptor]bool)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if g.direct[current] {
+			found[current] = true
+		}
+		for _, parent := range g.parents[current] {
+			if !visited[parent] {
+				visited[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+
+	// Report in graph order so results are stable
+	return g.filter(func(p PackageDescriptor) bool { return found[p] })
+}
+
+// DependencyRisk connects a package with issues from a job to the direct dependencies that pull it in
+type DependencyRisk struct {
+	Package      PackageDescriptor
+	Status       PackageStatusExtended
+	Direct       bool
+	IntroducedBy []PackageDescriptor // Direct dependencies the package is reachable from
+	Path         []PackageDescriptor // Shortest chain from a direct dependency to the package
+}
+
+// JoinJob returns a DependencyRisk for every package in the job that has issues and is present in
+// the graph, ordered by package score with the riskiest first
+func (g *DependencyGraph) JoinJob(job *JobStatusResponseForPackageStatusExtended) []DependencyRisk {
+	if job == nil {
+		return nil
+	}
+
+	var risks []DependencyRisk
+	for _, status := range job.Packages {
+		if len(status.Issues) == 0 {
+			continue
+		}
+		pkg, ok := g.lookup(status.Name, status.Version, jobPackageType(status.Type))
+		if !ok {
+			continue
+		}
+
+		risk := DependencyRisk{
+			Package:      pkg,
+			Status:       status,
+			Direct:       g.direct[pkg],
+			IntroducedBy: g.IntroducedBy(pkg),
+		}
+		if paths := g.Paths(pkg, 1); len(paths) > 0 {
+			risk.Path = paths[0]
+		}
+		risks = append(risks, risk)
+	}
+
+	sort.SliceStable(risks, func(i, j int) bool {
+		return packageScore(risks[i].Status) < packageScore(risks[j].Status)
+	})
+	return risks
+}
+
+// NewDependencyGraphFromJob builds a graph from the dependency lists in a verbose job. Jobs don't
+// record which packages the project requires itself, so packages nothing else depends on are
+// treated as direct.
+func NewDependencyGraphFromJob(job *JobStatusResponseForPackageStatusExtended) *DependencyGraph {
+	g := NewDependencyGraph()
+	if job == nil {
+		return g
+	}
+
+	for _, status := range job.Packages {
+		g.AddPackage(PackageDescriptor{Name: status.Name, Version: status.Version, Type: jobPackageType(status.Type)}, false)
+	}
+	for _, status := range job.Packages {
+		parent := PackageDescriptor{Name: status.Name, Version: status.Version, Type: jobPackageType(status.Type)}
+		for _, name := range sortedKeys(status.Dependencies.AdditionalProperties) {
+			if child, ok := g.lookup(name, status.Dependencies.AdditionalProperties[name], parent.Type); ok {
+				g.AddEdge(parent, child)
+			}
+		}
+	}
+	g.markRoots()
+	return g
+}
+
+// NewDependencyGraphFromProject builds a graph from the DepSpecs of a project's dependencies.
+// Packages nothing else depends on are treated as direct.
+func NewDependencyGraphFromProject(project *ProjectResponse) *DependencyGraph {
+	g := NewDependencyGraph()
+	if project == nil {
+		return g
+	}
+
+	for _, dep := range project.Dependencies {
+		g.AddPackage(PackageDescriptor{Name: dep.Name, Version: dep.Version, Type: PackageType(dep.Registry)}, false)
+	}
+	for _, dep := range project.Dependencies {
+		parent := PackageDescriptor{Name: dep.Name, Version: dep.Version, Type: PackageType(dep.Registry)}
+		for _, spec := range dep.DepSpecs {
+			if child, ok := g.lookup(spec.Name, spec.Version, PackageType(spec.Registry)); ok {
+				g.AddEdge(parent, child)
+			}
+		}
+	}
+	g.markRoots()
+	return g
+}
+
+// ParseDependencyGraph parses a lockfile into a DependencyGraph. Graphs can be built from
+// package-lock.json, yarn.lock, poetry.lock and Gemfile.lock files.
+func ParseDependencyGraph(lockfilePath string) (*DependencyGraph, error) {
+	parser, err := NewParserRegistry().Detect(lockfilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(lockfilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch parser.Name() {
+	case "npm":
+		return NpmDependencyGraph(data)
+	case "yarn-v1", "yarn-berry":
+		return YarnDependencyGraph(data)
+	case "poetry":
+		return PoetryDependencyGraph(data)
+	case "gemfile":
+		lock, err := ParseGemfileLock(data)
+		if err != nil {
+			return nil, err
+		}
+		return lock.DependencyGraph(), nil
+	}
+	return nil, fmt.Errorf("ParseDependencyGraph(): %s lockfiles do not record dependency relationships", parser.Name())
+}
+
+// lookup resolves a dependency reference to a package in the graph. An exact version match is
+// preferred; otherwise the reference resolves when exactly one version of the name is present.
+// An empty pkgType matches any ecosystem.
+func (g *DependencyGraph) lookup(name string, version string, pkgType PackageType) (PackageDescriptor, bool) {
+	var candidates []PackageDescriptor
+	for _, pkg := range g.byName[name] {
+		if pkgType != "" && pkg.Type != "" && pkg.Type != pkgType {
+			continue
+		}
+		if pkg.Version == version {
+			return pkg, true
+		}
+		candidates = append(candidates, pkg)
+	}
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+	return PackageDescriptor{}, false
+}
+
+// markRoots marks packages without dependents as direct, for sources that don't record direct
+// dependencies. Packages only reachable through a cycle all have dependents, so the first package
+// of each such cycle, in graph order, is marked direct as well.
+func (g *DependencyGraph) markRoots() {
+	var roots []PackageDescriptor
+	for _, pkg := range g.packages {
+		if len(g.parents[pkg]) == 0 {
+			g.direct[pkg] = true
+			roots = append(roots, pkg)
+		}
+	}
+
+	reached := g.reachable(g.children, roots...)
+	for _, pkg := range g.packages {
+		if reached[pkg] {
+			continue
+		}
+		// pkg starts a cycle when every package above it is also below it
+		below := g.reachable(g.children, pkg)
+		starts := true
+		for above := range g.reachable(g.parents, pkg) {
+			if !below[above] {
+				starts = false
+				break
+			}
+		}
+		if starts {
+			g.direct[pkg] = true
+			for p := range below {
+				reached[p] = true
+			}
+		}
+	}
+}
+
+// reachable returns the packages reachable from any of start along edges, including start
+func (g *DependencyGraph) reachable(edges map[PackageDescriptor][]PackageDescriptor, start ...PackageDescriptor) map[PackageDescriptor]bool {
+	visited := make(map[PackageDescriptor]bool)
+	queue := append([]PackageDescriptor(nil), start...)
+	for _, pkg := range start {
+		visited[pkg] = true
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range edges[current] {
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return visited
+}
+
+func (g *DependencyGraph) filter(include func(PackageDescriptor) bool) []PackageDescriptor {
+	var result []PackageDescriptor
+	for _, pkg := range g.packages {
+		if include(pkg) {
+			result = append(result, pkg)
+		}
+	}
+	return result
+}
+
+func chainContains(chain []PackageDescriptor, pkg PackageDescriptor) bool {
+	for _, p := range chain {
+		if p == pkg {
+			return true
+		}
+	}
+	return false
+}
+
+// jobPackageType converts the untyped Type field of a job package to a PackageType
+func jobPackageType(t interface{}) PackageType {
+	if t == nil {
+		return ""
+	}
+	return PackageType(fmt.Sprint(t))
+}
+
+func packageScore(status PackageStatusExtended) float64 {
+	if status.PackageScore == nil {
+		return 1
+	}
+	return *status.PackageScore
+}
@@ -0,0 +1,205 @@
+package phylum
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDependencyGraph_Paths(t *testing.T) {
+	a := PackageDescriptor{Name: "a", Version: "1.0.0", Type: Npm}
+	b := PackageDescriptor{Name: "b", Version: "1.0.0", Type: Npm}
+	c := PackageDescriptor{Name: "c", Version: "1.0.0", Type: Npm}
+	lodash := PackageDescriptor{Name: "lodash", Version: "4.17.20", Type: Npm}
+
+	g := NewDependencyGraph()
+	g.AddPackage(a, true)
+	g.AddPackage(b, true)
+	g.AddEdge(a, c)
+	g.AddEdge(c, lodash)
+	g.AddEdge(b, lodash)
+	g.AddEdge(lodash, c) // cycles must not loop forever
+
+	tests := []struct {
+		name  string
+		pkg   PackageDescriptor
+		limit int
+		want  [][]PackageDescriptor
+	}{
+		{"shortest first", lodash, 0, [][]PackageDescriptor{{b, lodash}, {a, c, lodash}}},
+		{"limit", lodash, 1, [][]PackageDescriptor{{b, lodash}}},
+		{"through cycle", c, 0, [][]PackageDescriptor{{a, c}, {b, lodash, c}}},
+		{"direct", a, 0, [][]PackageDescriptor{{a}}},
+		{"missing", PackageDescriptor{Name: "missing"}, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.Paths(tt.pkg, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Paths() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := g.IntroducedBy(lodash); !reflect.DeepEqual(got, []PackageDescriptor{a, b}) {
+		t.Errorf("IntroducedBy() got = %v, want %v", got, []PackageDescriptor{a, b})
+	}
+	if got := g.TransitivePackages(); !reflect.DeepEqual(got, []PackageDescriptor{c, lodash}) {
+		t.Errorf("TransitivePackages() got = %v, want %v", got, []PackageDescriptor{c, lodash})
+	}
+}
+
+func TestDependencyGraph_PathsLadder(t *testing.T) {
+	// Every layer depends on both packages of the next, so there are 2^40 chains to the leaf
+	g := NewDependencyGraph()
+	pkg := func(layer int, side string) PackageDescriptor {
+		return PackageDescriptor{Name: fmt.Sprintf("%s%d", side, layer), Version: "1.0.0", Type: Npm}
+	}
+	root := pkg(0, "root")
+	g.AddPackage(root, true)
+	g.AddEdge(root, pkg(1, "a"))
+	g.AddEdge(root, pkg(1, "b"))
+	for layer := 1; layer < 40; layer++ {
+		for _, parent := range []string{"a", "b"} {
+			g.AddEdge(pkg(layer, parent), pkg(layer+1, "a"))
+			g.AddEdge(pkg(layer, parent), pkg(layer+1, "b"))
+		}
+	}
+	leaf := PackageDescriptor{Name: "leaf", Version: "1.0.0", Type: Npm}
+	g.AddEdge(pkg(40, "a"), leaf)
+	g.AddEdge(pkg(40, "b"), leaf)
+
+	// A cycle no direct dependency leads to is never searched
+	orphan := PackageDescriptor{Name: "orphan", Version: "1.0.0", Type: Npm}
+	g.AddEdge(orphan, leaf)
+	g.AddEdge(leaf, orphan)
+
+	paths := g.Paths(leaf, 3)
+	if len(paths) != 3 {
+		t.Fatalf("Paths() got %v paths, want 3", len(paths))
+	}
+	for _, path := range paths {
+		if len(path) != 42 || path[0] != root || path[41] != leaf {
+			t.Errorf("Paths() got = %v, want a chain from root to leaf", path)
+		}
+	}
+}
+
+func TestDependencyGraph_markRoots(t *testing.T) {
+	a := PackageDescriptor{Name: "a", Version: "1.0.0", Type: Npm}
+	b := PackageDescriptor{Name: "b", Version: "1.0.0", Type: Npm}
+	c := PackageDescriptor{Name: "c", Version: "1.0.0", Type: Npm}
+	d := PackageDescriptor{Name: "d", Version: "1.0.0", Type: Npm}
+	e := PackageDescriptor{Name: "e", Version: "1.0.0", Type: Npm}
+
+	// d and e depend on each other and nothing depends on them; c is below the cycle of a and b
+	g := NewDependencyGraph()
+	g.AddPackage(c, false)
+	g.AddEdge(a, b)
+	g.AddEdge(b, a)
+	g.AddEdge(b, c)
+	g.AddEdge(e, d)
+	g.AddEdge(d, e)
+	g.markRoots()
+
+	if got, want := g.DirectPackages(), []PackageDescriptor{a, e}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DirectPackages() got = %v, want %v", got, want)
+	}
+	if got, want := g.Paths(c, 0), [][]PackageDescriptor{{a, b, c}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Paths() got = %v, want %v", got, want)
+	}
+}
+
+func TestParseDependencyGraph(t *testing.T) {
+	type args struct {
+		lockfilePath string
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantLen    int
+		wantDirect int
+		wantErr    bool
+	}{
+		{"package-lock", args{"test_lockfiles/package-lock.json"}, 52, 1, false},
+		{"package-lock v1", args{"test_lockfiles/package-lock-v6.json"}, 17, 2, false},
+		{"yarn berry", args{"test_lockfiles/yarn.lock"}, 53, 4, false},
+		{"yarn berry patch", args{"test_lockfiles/yarn-berry-patch.lock"}, 4, 1, false},
+		{"yarn v1", args{"test_lockfiles/yarn-v1.lock"}, 17, 2, false},
+		{"poetry.lock", args{"test_lockfiles/poetry.lock"}, 45, 7, false},
+		{"Gemfile.lock", args{"test_lockfiles/Gemfile.lock"}, 214, 46, false},
+		{"requirements.txt", args{"test_lockfiles/requirements.txt"}, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDependencyGraph(tt.args.lockfilePath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseDependencyGraph() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if len(got.Packages()) != tt.wantLen {
+				t.Errorf("ParseDependencyGraph(): len of packages got = %v, want %v", len(got.Packages()), tt.wantLen)
+			}
+			if len(got.DirectPackages()) != tt.wantDirect {
+				t.Errorf("ParseDependencyGraph(): len of direct packages got = %v, want %v", len(got.DirectPackages()), tt.wantDirect)
+			}
+		})
+	}
+
+	g, err := ParseDependencyGraph("test_lockfiles/package-lock.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	express := PackageDescriptor{Name: "express", Version: "4.17.3", Type: Npm}
+	if !g.IsDirect(express) {
+		t.Errorf("IsDirect(%v) got = false, want true", express)
+	}
+	paths := g.Paths(PackageDescriptor{Name: "mime", Version: "1.6.0", Type: Npm}, 0)
+	if len(paths) == 0 {
+		t.Errorf("Paths(): no path found to mime@1.6.0")
+	}
+	for _, path := range paths {
+		if path[0] != express {
+			t.Errorf("Paths(): path %v should start at %v", path, express)
+		}
+	}
+}
+
+func TestDependencyGraph_JoinJob(t *testing.T) {
+	app := PackageDescriptor{Name: "app-lib", Version: "1.0.0", Type: Npm}
+	risky := PackageDescriptor{Name: "risky", Version: "0.1.0", Type: Npm}
+	worse := PackageDescriptor{Name: "worse", Version: "2.0.0", Type: Npm}
+	g := NewDependencyGraph()
+	g.AddPackage(app, true)
+	g.AddEdge(app, risky)
+	g.AddPackage(worse, true)
+
+	low, lower := 0.4, 0.2
+	job := &JobStatusResponseForPackageStatusExtended{
+		Packages: []PackageStatusExtended{
+			{Name: "app-lib", Version: "1.0.0", Type: "npm"},
+			{Name: "risky", Version: "0.1.0", Type: "npm", PackageScore: &low, Issues: []Issue{{Title: "typosquat"}}},
+			{Name: "worse", Version: "2.0.0", Type: "npm", PackageScore: &lower, Issues: []Issue{{Title: "malware"}}},
+			{Name: "elsewhere", Version: "1.0.0", Type: "npm", PackageScore: &lower, Issues: []Issue{{Title: "not in graph"}}},
+		},
+	}
+
+	got := g.JoinJob(job)
+	if len(got) != 2 {
+		t.Fatalf("JoinJob(): len got = %v, want 2", len(got))
+	}
+	if got[0].Package != worse || !got[0].Direct {
+		t.Errorf("JoinJob(): first risk got = %v, want direct %v", got[0].Package, worse)
+	}
+	if got[1].Package != risky || got[1].Direct {
+		t.Errorf("JoinJob(): second risk got = %v, want transitive %v", got[1].Package, risky)
+	}
+	if !reflect.DeepEqual(got[1].IntroducedBy, []PackageDescriptor{app}) {
+		t.Errorf("JoinJob(): IntroducedBy got = %v, want %v", got[1].IntroducedBy, []PackageDescriptor{app})
+	}
+	if !reflect.DeepEqual(got[1].Path, []PackageDescriptor{app, risky}) {
+		t.Errorf("JoinJob(): Path got = %v, want %v", got[1].Path, []PackageDescriptor{app, risky})
+	}
+}
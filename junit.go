@@ -0,0 +1,166 @@
+package phylum
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JUnitOptions control how a job is reported as a JUnit test suite
+type JUnitOptions struct {
+	Name       string          // Suite name, defaults to the project name
+	Thresholds *RiskThresholds // Evaluated instead of the thresholds recorded on the job when set
+}
+
+// JUnitTestSuites is the root element of a JUnit XML report
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr,omitempty"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite groups the test cases of one job
+type JUnitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Properties []JUnitProperty `xml:"properties>property,omitempty"`
+	TestCases  []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitProperty is a name/value pair attached to a suite
+type JUnitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// JUnitTestCase is the result for one package
+type JUnitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// JUnitFailure marks a package that breached a threshold
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitSkipped marks a package whose analysis has not completed
+type JUnitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// NewJUnitFromJob reports a verbose job as a JUnit suite with one test case per package. Packages
+// that breach a threshold fail, with their issues as the failure text; packages without a score
+// yet are skipped. The suite properties carry the project name, label, job ID and total score.
+func NewJUnitFromJob(job *JobStatusResponseForPackageStatusExtended, opts *JUnitOptions) *JUnitTestSuites {
+	if opts == nil {
+		opts = &JUnitOptions{}
+	}
+	suites := &JUnitTestSuites{Name: "phylum"}
+	if job == nil {
+		return suites
+	}
+
+	suite := JUnitTestSuite{Name: opts.Name}
+	if suite.Name == "" {
+		suite.Name = job.ProjectName
+	}
+	label := ""
+	if job.Label != nil {
+		label = *job.Label
+	}
+	suite.Properties = []JUnitProperty{
+		{Name: "project", Value: job.ProjectName},
+		{Name: "label", Value: label},
+		{Name: "job_id", Value: job.JobId.String()},
+		{Name: "score", Value: fmt.Sprintf("%.2f", job.Score)},
+	}
+
+	breaches := junitBreaches(job, opts.Thresholds)
+	for _, status := range job.Packages {
+		testCase := JUnitTestCase{
+			ClassName: string(jobPackageType(status.Type)),
+			Name:      status.Name + "@" + status.Version,
+			SystemOut: junitIssueText(status.Issues),
+		}
+		pkg := PackageDescriptor{Name: status.Name, Version: status.Version, Type: jobPackageType(status.Type)}
+		switch reasons := breaches[pkg]; {
+		case len(reasons) > 0:
+			testCase.Failure = &JUnitFailure{
+				Message: "breaches thresholds: " + strings.Join(reasons, "; "),
+				Type:    "threshold",
+				Text:    testCase.SystemOut,
+			}
+			testCase.SystemOut = ""
+			suite.Failures++
+		case status.PackageScore == nil:
+			testCase.Skipped = &JUnitSkipped{Message: "analysis incomplete"}
+			suite.Skipped++
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	suite.Tests = len(suite.TestCases)
+
+	suites.Suites = []JUnitTestSuite{suite}
+	suites.Tests, suites.Failures = suite.Tests, suite.Failures
+	return suites
+}
+
+// junitBreaches lists the threshold breaches of each package
+func junitBreaches(job *JobStatusResponseForPackageStatusExtended, thresholds *RiskThresholds) map[PackageDescriptor][]string {
+	breaches := make(map[PackageDescriptor][]string)
+	if thresholds != nil {
+		verdict := EvaluatePolicy(job, thresholds, nil)
+		for _, v := range verdict.Violations {
+			breaches[v.Package] = append(breaches[v.Package], fmt.Sprintf("%s %.2f < %.2f", v.Domain, v.Score, v.Threshold))
+		}
+		return breaches
+	}
+
+	for _, v := range JobThresholdViolations(job) {
+		pkg := PackageDescriptor{Name: v.Name, Version: v.Version, Type: v.Type}
+		breaches[pkg] = append(breaches[pkg], fmt.Sprintf("%s %.2f < %.2f", v.Domain, v.Score, v.Threshold))
+	}
+	return breaches
+}
+
+func junitIssueText(issues []Issue) string {
+	var b strings.Builder
+	for i, issue := range issues {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "[%s] %s", strings.ToUpper(string(issue.Severity)), issue.Title)
+		if description := strings.TrimSpace(issue.Description); description != "" {
+			b.WriteString("\n" + description)
+		}
+	}
+	return b.String()
+}
+
+// WriteXML writes the report in the JUnit XML format
+func (s *JUnitTestSuites) WriteXML(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("WriteXML(): %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return fmt.Errorf("WriteXML(): %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("WriteXML(): %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package phylum
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewJUnitFromJob(t *testing.T) {
+	score := func(v float64) *float64 { return &v }
+	label := "main"
+	job := &JobStatusResponseForPackageStatusExtended{
+		ProjectName: "web",
+		Label:       &label,
+		Score:       0.42,
+		Packages: []PackageStatusExtended{
+			{Name: "lodash", Version: "4.17.21", Type: "npm", PackageScore: score(0.9)},
+			{
+				Name: "event-stream", Version: "3.3.6", Type: "npm", PackageScore: score(0.2),
+				RiskVectors: PackageStatusExtended_RiskVectors{AdditionalProperties: map[string]float64{"malicious_code": 0.1}},
+				Issues:      []Issue{{Title: "Malicious code", Description: "Steals wallets", Severity: Critical}},
+			},
+			{Name: "left-pad", Version: "1.3.0", Type: "npm"},
+		},
+	}
+	job.Thresholds.Total = 0.5
+	job.Thresholds.Malicious = 0.6
+
+	tests := []struct {
+		name        string
+		opts        *JUnitOptions
+		wantMessage string
+	}{
+		{"job thresholds", nil, "breaches thresholds: total 0.20 < 0.50; malicious_code 0.10 < 0.60"},
+		{
+			"policy thresholds",
+			&JUnitOptions{Thresholds: &RiskThresholds{Total: ThresholdDescriptor{Active: true, Threshold: 0.3, Action: ThresholdViolationActionBreak}}},
+			"breaches thresholds: total 0.20 < 0.30",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewJUnitFromJob(job, tt.opts)
+			if report.Tests != 3 || report.Failures != 1 {
+				t.Fatalf("NewJUnitFromJob() tests got = %v, failures %v", report.Tests, report.Failures)
+			}
+			suite := report.Suites[0]
+			if suite.Name != "web" || suite.Skipped != 1 {
+				t.Errorf("NewJUnitFromJob() suite got = %v, skipped %v", suite.Name, suite.Skipped)
+			}
+			wantProperties := []JUnitProperty{
+				{"project", "web"}, {"label", "main"}, {"job_id", "00000000-0000-0000-0000-000000000000"}, {"score", "0.42"},
+			}
+			if !reflect.DeepEqual(suite.Properties, wantProperties) {
+				t.Errorf("NewJUnitFromJob() properties got = %v", suite.Properties)
+			}
+			failure := suite.TestCases[1].Failure
+			if failure == nil || failure.Message != tt.wantMessage || failure.Text != "[CRITICAL] Malicious code\nSteals wallets" {
+				t.Errorf("NewJUnitFromJob() failure got = %+v", failure)
+			}
+			if suite.TestCases[0].Failure != nil || suite.TestCases[2].Skipped == nil {
+				t.Errorf("NewJUnitFromJob() test cases got = %+v", suite.TestCases)
+			}
+		})
+	}
+
+	var out bytes.Buffer
+	if err := NewJUnitFromJob(job, nil).WriteXML(&out); err != nil {
+		t.Fatalf("WriteXML() error = %v", err)
+	}
+	var decoded JUnitTestSuites
+	if err := xml.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("WriteXML() wrote invalid XML: %v", err)
+	}
+	if !strings.Contains(out.String(), `<testcase classname="npm" name="event-stream@3.3.6">`) {
+		t.Errorf("WriteXML() output got = %s", out.String())
+	}
+}
+
+func TestNewJUnitFromJobSameNameAcrossEcosystems(t *testing.T) {
+	score := func(v float64) *float64 { return &v }
+	job := &JobStatusResponseForPackageStatusExtended{
+		Packages: []PackageStatusExtended{
+			{Name: "requests", Version: "2.28.1", Type: "npm", PackageScore: score(0.9)},
+			{Name: "requests", Version: "2.28.1", Type: "pypi", PackageScore: score(0.2)},
+		},
+	}
+	job.Thresholds.Total = 0.5
+
+	for _, opts := range []*JUnitOptions{nil, {Thresholds: &RiskThresholds{Total: ThresholdDescriptor{Active: true, Threshold: 0.5}}}} {
+		suite := NewJUnitFromJob(job, opts).Suites[0]
+		if suite.Failures != 1 || suite.TestCases[0].Failure != nil || suite.TestCases[1].Failure == nil {
+			t.Errorf("NewJUnitFromJob() test cases got = %+v, want only the pypi package to fail", suite.TestCases)
+		}
+	}
+}
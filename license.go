@@ -0,0 +1,548 @@
+package phylum
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// LicenseCategory groups licenses by the obligations they place on users
+type LicenseCategory string
+
+const (
+	LicensePermissive     LicenseCategory = "permissive"
+	LicenseWeakCopyleft   LicenseCategory = "weak_copyleft"
+	LicenseStrongCopyleft LicenseCategory = "strong_copyleft"
+	LicenseUnknown        LicenseCategory = "unknown"
+)
+
+// LicenseStatus is the outcome of checking a license against a LicensePolicy
+type LicenseStatus string
+
+const (
+	LicenseAllowed    LicenseStatus = "allowed"
+	LicenseNotAllowed LicenseStatus = "not_allowed" // Not on a non-empty allow list
+	LicenseDenied     LicenseStatus = "denied"
+	LicenseMissing    LicenseStatus = "missing"
+	LicenseInvalid    LicenseStatus = "invalid" // Not a valid SPDX expression
+)
+
+// LicenseExpression is a parsed SPDX license expression. Leaves have a License and optionally an
+// Exception; compound expressions have an Op of "AND" or "OR" and two or more Operands.
+type LicenseExpression struct {
+	Op        string
+	Operands  []*LicenseExpression
+	License   string
+	Exception string
+}
+
+// ParseLicenseExpression parses an SPDX license expression such as
+// "(MIT OR Apache-2.0) AND GPL-2.0-only WITH Classpath-exception-2.0". WITH binds tighter than
+// AND, which binds tighter than OR. Operators are matched case-insensitively and common
+// non-SPDX names such as "Apache 2.0" or "MIT License" are mapped to their SPDX identifiers.
+func ParseLicenseExpression(expression string) (*LicenseExpression, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return nil, fmt.Errorf("ParseLicenseExpression(): empty expression")
+	}
+	if id, ok := licenseAliases[strings.ToLower(expression)]; ok {
+		return &LicenseExpression{License: id}, nil
+	}
+
+	p := &licenseParser{tokens: tokenizeLicenseExpression(expression)}
+	expr, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ParseLicenseExpression(): %q: %w", expression, err)
+	}
+	return expr, nil
+}
+
+func tokenizeLicenseExpression(expression string) []string {
+	var tokens []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range expression {
+		switch {
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+type licenseParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *licenseParser) peekOperator(op string) bool {
+	return p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], op)
+}
+
+func (p *licenseParser) parseOr() (*LicenseExpression, error) {
+	return p.parseBinary("OR", p.parseAnd)
+}
+
+func (p *licenseParser) parseAnd() (*LicenseExpression, error) {
+	return p.parseBinary("AND", p.parseWith)
+}
+
+// parseBinary parses operands joined by op, flattening nested uses of the same operator
+func (p *licenseParser) parseBinary(op string, operand func() (*LicenseExpression, error)) (*LicenseExpression, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*LicenseExpression{first}
+	for p.peekOperator(op) {
+		p.pos++
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+
+	expr := &LicenseExpression{Op: op}
+	for _, operand := range operands {
+		if operand.Op == op {
+			expr.Operands = append(expr.Operands, operand.Operands...)
+		} else {
+			expr.Operands = append(expr.Operands, operand)
+		}
+	}
+	return expr, nil
+}
+
+func (p *licenseParser) parseWith() (*LicenseExpression, error) {
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.peekOperator("WITH") {
+		return expr, nil
+	}
+	if expr.License == "" {
+		return nil, fmt.Errorf("WITH must follow a license identifier")
+	}
+	p.pos++
+	if p.pos >= len(p.tokens) || !isLicenseIdentifier(p.tokens[p.pos]) {
+		return nil, fmt.Errorf("WITH must be followed by an exception identifier")
+	}
+	expr.Exception = p.tokens[p.pos]
+	p.pos++
+	return expr, nil
+}
+
+func (p *licenseParser) parsePrimary() (*LicenseExpression, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	token := p.tokens[p.pos]
+	switch {
+	case token == "(":
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return expr, nil
+	case strings.EqualFold(token, "AND"), strings.EqualFold(token, "OR"), strings.EqualFold(token, "WITH"), token == ")":
+		return nil, fmt.Errorf("unexpected %q", token)
+	case !isLicenseIdentifier(token):
+		return nil, fmt.Errorf("invalid license identifier %q", token)
+	}
+	p.pos++
+	return &LicenseExpression{License: canonicalLicenseID(token)}, nil
+}
+
+// isLicenseIdentifier reports whether s is made of the characters allowed in SPDX identifiers
+func isLicenseIdentifier(s string) bool {
+	for _, r := range s {
+		if !(r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))) && !strings.ContainsRune(".-+:", r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Licenses returns the license identifiers used in the expression, in order of appearance
+func (e *LicenseExpression) Licenses() []string {
+	if e.Op == "" {
+		return []string{e.License}
+	}
+	var licenses []string
+	for _, operand := range e.Operands {
+		for _, license := range operand.Licenses() {
+			if !containsString(licenses, license) {
+				licenses = append(licenses, license)
+			}
+		}
+	}
+	return licenses
+}
+
+// String formats the expression in SPDX syntax, adding parentheses only where needed
+func (e *LicenseExpression) String() string {
+	if e.Op == "" {
+		if e.Exception != "" {
+			return e.License + " WITH " + e.Exception
+		}
+		return e.License
+	}
+
+	parts := make([]string, len(e.Operands))
+	for i, operand := range e.Operands {
+		parts[i] = operand.String()
+		if operand.Op == "OR" && e.Op == "AND" {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " "+e.Op+" ")
+}
+
+// Category returns the category of the expression. A choice between licenses (OR) takes the least
+// restrictive option and a combination (AND) the most restrictive. An exception such as
+// Classpath-exception-2.0 on a strong copyleft license makes it weak copyleft. Unknown licenses
+// order as the most restrictive.
+func (e *LicenseExpression) Category() LicenseCategory {
+	if e.Op == "" {
+		category := LicenseCategoryOf(e.License)
+		if e.Exception != "" && category == LicenseStrongCopyleft {
+			return LicenseWeakCopyleft
+		}
+		return category
+	}
+
+	result := e.Operands[0].Category()
+	for _, operand := range e.Operands[1:] {
+		category := operand.Category()
+		if (e.Op == "OR") == (licenseCategoryRank(category) < licenseCategoryRank(result)) {
+			result = category
+		}
+	}
+	return result
+}
+
+func licenseCategoryRank(category LicenseCategory) int {
+	switch category {
+	case LicensePermissive:
+		return 0
+	case LicenseWeakCopyleft:
+		return 1
+	case LicenseStrongCopyleft:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// LicenseCategoryOf returns the category of a single SPDX license identifier
+func LicenseCategoryOf(license string) LicenseCategory {
+	id := strings.ToUpper(canonicalLicenseID(license))
+	id = strings.TrimSuffix(id, "+")
+	id = strings.TrimSuffix(id, "-ONLY")
+	id = strings.TrimSuffix(id, "-OR-LATER")
+
+	if category, ok := licenseCategories[id]; ok {
+		return category
+	}
+	switch {
+	case strings.HasPrefix(id, "AGPL-"), strings.HasPrefix(id, "GPL-"), strings.HasPrefix(id, "CC-BY-SA-"),
+		strings.HasPrefix(id, "EUPL-"), strings.HasPrefix(id, "OSL-"), strings.HasPrefix(id, "SSPL-"):
+		return LicenseStrongCopyleft
+	case strings.HasPrefix(id, "LGPL-"), strings.HasPrefix(id, "MPL-"), strings.HasPrefix(id, "EPL-"),
+		strings.HasPrefix(id, "CDDL-"), strings.HasPrefix(id, "CPL-"):
+		return LicenseWeakCopyleft
+	case strings.HasPrefix(id, "BSD-"), strings.HasPrefix(id, "CC0-"), strings.HasPrefix(id, "MIT-"):
+		return LicensePermissive
+	case strings.HasPrefix(id, "CC-BY-") && !strings.Contains(id, "-NC") && !strings.Contains(id, "-ND"):
+		return LicensePermissive
+	}
+	return LicenseUnknown
+}
+
+var licenseCategories = map[string]LicenseCategory{
+	"0BSD":                     LicensePermissive,
+	"APACHE-1.1":               LicensePermissive,
+	"APACHE-2.0":               LicensePermissive,
+	"ARTISTIC-2.0":             LicensePermissive,
+	"BLUEOAK-1.0.0":            LicensePermissive,
+	"BSL-1.0":                  LicensePermissive,
+	"ISC":                      LicensePermissive,
+	"LICENSEREF-PUBLIC-DOMAIN": LicensePermissive,
+	"MIT":                      LicensePermissive,
+	"NCSA":                     LicensePermissive,
+	"POSTGRESQL":               LicensePermissive,
+	"PSF-2.0":                  LicensePermissive,
+	"PYTHON-2.0":               LicensePermissive,
+	"RUBY":                     LicensePermissive,
+	"UNICODE-DFS-2016":         LicensePermissive,
+	"UNLICENSE":                LicensePermissive,
+	"UPL-1.0":                  LicensePermissive,
+	"WTFPL":                    LicensePermissive,
+	"X11":                      LicensePermissive,
+	"ZLIB":                     LicensePermissive,
+	"ZPL-2.1":                  LicensePermissive,
+	"MS-PL":                    LicenseWeakCopyleft,
+	"MS-RL":                    LicenseWeakCopyleft,
+}
+
+// licenseAliases maps common non-SPDX license names, lowercased, to SPDX identifiers
+var licenseAliases = map[string]string{
+	"mit license":                        "MIT",
+	"the mit license":                    "MIT",
+	"expat":                              "MIT",
+	"apache 2":                           "Apache-2.0",
+	"apache 2.0":                         "Apache-2.0",
+	"apache-2":                           "Apache-2.0",
+	"apache2":                            "Apache-2.0",
+	"apache license 2.0":                 "Apache-2.0",
+	"apache license, version 2.0":        "Apache-2.0",
+	"apache software license":            "Apache-2.0",
+	"asl 2.0":                            "Apache-2.0",
+	"bsd":                                "BSD-3-Clause",
+	"bsd license":                        "BSD-3-Clause",
+	"new bsd":                            "BSD-3-Clause",
+	"new bsd license":                    "BSD-3-Clause",
+	"modified bsd":                       "BSD-3-Clause",
+	"3-clause bsd":                       "BSD-3-Clause",
+	"simplified bsd":                     "BSD-2-Clause",
+	"2-clause bsd":                       "BSD-2-Clause",
+	"isc license":                        "ISC",
+	"gplv2":                              "GPL-2.0-only",
+	"gpl v2":                             "GPL-2.0-only",
+	"gplv3":                              "GPL-3.0-only",
+	"gpl v3":                             "GPL-3.0-only",
+	"lgplv2.1":                           "LGPL-2.1-only",
+	"lgplv3":                             "LGPL-3.0-only",
+	"agplv3":                             "AGPL-3.0-only",
+	"mpl 2.0":                            "MPL-2.0",
+	"mozilla public license 2.0":         "MPL-2.0",
+	"eclipse public license 2.0":         "EPL-2.0",
+	"the unlicense":                      "Unlicense",
+	"public domain":                      "LicenseRef-Public-Domain",
+	"psf":                                "PSF-2.0",
+	"python software foundation license": "PSF-2.0",
+	"cc0":                                "CC0-1.0",
+}
+
+// canonicalLicenseID fixes the case of well known SPDX identifiers and maps single-word aliases
+func canonicalLicenseID(license string) string {
+	if id, ok := licenseAliases[strings.ToLower(license)]; ok {
+		return id
+	}
+	for _, id := range []string{"MIT", "Apache-2.0", "BSD-2-Clause", "BSD-3-Clause", "ISC", "0BSD", "Unlicense", "Zlib", "WTFPL", "MPL-2.0", "CC0-1.0"} {
+		if strings.EqualFold(license, id) {
+			return id
+		}
+	}
+	return license
+}
+
+// LicensePolicy decides which licenses a project may use. Denied licenses and categories always
+// fail. When Allow or AllowCategories is non-empty, every other license is not allowed.
+type LicensePolicy struct {
+	Allow           []string
+	Deny            []string
+	AllowCategories []LicenseCategory
+	DenyCategories  []LicenseCategory
+}
+
+// Check evaluates an expression, returning its status and the licenses responsible for a denied
+// or not allowed result. A choice (OR) passes when any option passes; a combination (AND)
+// requires every license to pass.
+func (p *LicensePolicy) Check(expr *LicenseExpression) (LicenseStatus, []string) {
+	if expr.Op == "" {
+		status := p.checkLicense(expr)
+		if status == LicenseAllowed {
+			return status, nil
+		}
+		return status, []string{expr.License}
+	}
+
+	var result LicenseStatus
+	var offending []string
+	for i, operand := range expr.Operands {
+		status, licenses := p.Check(operand)
+		switch {
+		case i == 0:
+			result, offending = status, licenses
+		case expr.Op == "AND" && licenseStatusRank(status) > licenseStatusRank(result),
+			expr.Op == "OR" && licenseStatusRank(status) < licenseStatusRank(result):
+			result, offending = status, licenses
+		case status == result && status != LicenseAllowed:
+			offending = append(offending, licenses...)
+		}
+	}
+	if result == LicenseAllowed {
+		offending = nil
+	}
+	return result, offending
+}
+
+func (p *LicensePolicy) checkLicense(expr *LicenseExpression) LicenseStatus {
+	category := expr.Category()
+	if licenseListContains(p.Deny, expr.License) || categoryListContains(p.DenyCategories, category) {
+		return LicenseDenied
+	}
+	if len(p.Allow) == 0 && len(p.AllowCategories) == 0 {
+		return LicenseAllowed
+	}
+	if licenseListContains(p.Allow, expr.License) || categoryListContains(p.AllowCategories, category) {
+		return LicenseAllowed
+	}
+	return LicenseNotAllowed
+}
+
+func licenseStatusRank(status LicenseStatus) int {
+	switch status {
+	case LicenseAllowed:
+		return 0
+	case LicenseNotAllowed:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func licenseListContains(list []string, license string) bool {
+	for _, entry := range list {
+		if strings.EqualFold(canonicalLicenseID(entry), license) {
+			return true
+		}
+	}
+	return false
+}
+
+func categoryListContains(list []LicenseCategory, category LicenseCategory) bool {
+	for _, entry := range list {
+		if entry == category {
+			return true
+		}
+	}
+	return false
+}
+
+// LicensePolicy returns the license allow and deny lists of the policy file
+func (f *PolicyFile) LicensePolicy() *LicensePolicy {
+	return &LicensePolicy{Allow: f.Licenses.Allow, Deny: f.Licenses.Deny}
+}
+
+// PackageLicense is the license analysis of one package
+type PackageLicense struct {
+	Package    PackageDescriptor
+	License    string             // License as reported by Phylum
+	Expression *LicenseExpression // nil when the license is missing or invalid
+	Category   LicenseCategory
+	Status     LicenseStatus
+	Offending  []string // Licenses responsible for a denied or not allowed status
+}
+
+// Problem reports whether the license is missing, invalid, of unknown category or fails the policy
+func (l PackageLicense) Problem() bool {
+	return l.Status != LicenseAllowed || l.Category == LicenseUnknown
+}
+
+// CheckPackageLicense analyses the license of a package. A nil policy allows every license.
+func CheckPackageLicense(pkg PackageDescriptor, license *string, policy *LicensePolicy) PackageLicense {
+	result := PackageLicense{Package: pkg, Category: LicenseUnknown}
+	if license == nil || strings.TrimSpace(*license) == "" {
+		result.Status = LicenseMissing
+		return result
+	}
+	result.License = *license
+
+	expr, err := ParseLicenseExpression(*license)
+	if err != nil {
+		result.Status = LicenseInvalid
+		return result
+	}
+	result.Expression = expr
+	result.Category = expr.Category()
+	if policy == nil {
+		policy = &LicensePolicy{}
+	}
+	result.Status, result.Offending = policy.Check(expr)
+	return result
+}
+
+// LicenseReport summarises the licenses of a project
+type LicenseReport struct {
+	Packages   []PackageLicense
+	Problems   []PackageLicense // Packages with a missing, unknown or disallowed license
+	Categories map[LicenseCategory]int
+	Counts     map[string]uint32 // Packages per license as counted by Phylum, when available
+}
+
+// Pass reports whether no package has a denied or not allowed license
+func (r *LicenseReport) Pass() bool {
+	for _, pkg := range r.Problems {
+		if pkg.Status == LicenseDenied || pkg.Status == LicenseNotAllowed {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *LicenseReport) add(pkg PackageLicense) {
+	r.Packages = append(r.Packages, pkg)
+	r.Categories[pkg.Category]++
+	if pkg.Problem() {
+		r.Problems = append(r.Problems, pkg)
+	}
+}
+
+// LicenseReportFromJob builds a license report from the packages of a verbose job
+func LicenseReportFromJob(job *JobStatusResponseForPackageStatusExtended, policy *LicensePolicy) *LicenseReport {
+	report := &LicenseReport{Categories: map[LicenseCategory]int{}}
+	if job == nil {
+		return report
+	}
+	for _, status := range job.Packages {
+		pkg := PackageDescriptor{Name: status.Name, Version: status.Version, Type: jobPackageType(status.Type)}
+		report.add(CheckPackageLicense(pkg, status.License, policy))
+	}
+	return report
+}
+
+// LicenseReportFromProject builds a license report from a project's dependencies, including the
+// per-license counts from the project statistics
+func LicenseReportFromProject(project *ProjectResponse, policy *LicensePolicy) *LicenseReport {
+	report := &LicenseReport{Categories: map[LicenseCategory]int{}}
+	if project == nil {
+		return report
+	}
+	for _, dep := range project.Dependencies {
+		pkg := PackageDescriptor{Name: dep.Name, Version: dep.Version, Type: PackageType(dep.Registry)}
+		report.add(CheckPackageLicense(pkg, dep.License, policy))
+	}
+	if counts := project.Stats.Licenses.Counts.AdditionalProperties; len(counts) > 0 {
+		report.Counts = make(map[string]uint32, len(counts))
+		for license, count := range counts {
+			report.Counts[license] = count
+		}
+	}
+	return report
+}
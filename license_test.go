@@ -0,0 +1,134 @@
+package phylum
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLicenseExpression(t *testing.T) {
+	tests := []struct {
+		name         string
+		expression   string
+		wantString   string
+		wantLicenses []string
+		wantCategory LicenseCategory
+		wantErr      bool
+	}{
+		{"single", "MIT", "MIT", []string{"MIT"}, LicensePermissive, false},
+		{"lowercase id", "apache-2.0", "Apache-2.0", []string{"Apache-2.0"}, LicensePermissive, false},
+		{"alias", "Apache License, Version 2.0", "Apache-2.0", []string{"Apache-2.0"}, LicensePermissive, false},
+		{"or picks least restrictive", "GPL-3.0-only OR MIT", "GPL-3.0-only OR MIT", []string{"GPL-3.0-only", "MIT"}, LicensePermissive, false},
+		{"and picks most restrictive", "MIT AND LGPL-2.1-or-later", "MIT AND LGPL-2.1-or-later", []string{"MIT", "LGPL-2.1-or-later"}, LicenseWeakCopyleft, false},
+		{"precedence", "MIT OR Apache-2.0 AND GPL-2.0+", "MIT OR Apache-2.0 AND GPL-2.0+", []string{"MIT", "Apache-2.0", "GPL-2.0+"}, LicensePermissive, false},
+		{"parentheses", "(MIT OR Apache-2.0) AND AGPL-3.0-only", "(MIT OR Apache-2.0) AND AGPL-3.0-only", []string{"MIT", "Apache-2.0", "AGPL-3.0-only"}, LicenseStrongCopyleft, false},
+		{"flattened", "MIT AND (ISC AND 0BSD)", "MIT AND ISC AND 0BSD", []string{"MIT", "ISC", "0BSD"}, LicensePermissive, false},
+		{"exception", "GPL-2.0-only WITH Classpath-exception-2.0", "GPL-2.0-only WITH Classpath-exception-2.0", []string{"GPL-2.0-only"}, LicenseWeakCopyleft, false},
+		{"lowercase operators", "mit or isc", "MIT OR ISC", []string{"MIT", "ISC"}, LicensePermissive, false},
+		{"unknown", "LicenseRef-Proprietary", "LicenseRef-Proprietary", []string{"LicenseRef-Proprietary"}, LicenseUnknown, false},
+		{"empty", " ", "", nil, "", true},
+		{"dangling operator", "MIT AND", "", nil, "", true},
+		{"unbalanced", "(MIT OR ISC", "", nil, "", true},
+		{"with after group", "(MIT OR ISC) WITH LLVM-exception", "", nil, "", true},
+		{"free text", "See LICENSE file", "", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLicenseExpression(tt.expression)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLicenseExpression() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.String() != tt.wantString {
+				t.Errorf("String() got = %v, want %v", got.String(), tt.wantString)
+			}
+			if !reflect.DeepEqual(got.Licenses(), tt.wantLicenses) {
+				t.Errorf("Licenses() got = %v, want %v", got.Licenses(), tt.wantLicenses)
+			}
+			if got.Category() != tt.wantCategory {
+				t.Errorf("Category() got = %v, want %v", got.Category(), tt.wantCategory)
+			}
+		})
+	}
+}
+
+func TestLicensePolicy_Check(t *testing.T) {
+	policy := &LicensePolicy{
+		Allow:           []string{"Apache-2.0"},
+		AllowCategories: []LicenseCategory{LicensePermissive},
+		Deny:            []string{"agpl-3.0-only"},
+		DenyCategories:  []LicenseCategory{LicenseStrongCopyleft},
+	}
+	tests := []struct {
+		expression    string
+		wantStatus    LicenseStatus
+		wantOffending []string
+	}{
+		{"MIT", LicenseAllowed, nil},
+		{"AGPL-3.0-only", LicenseDenied, []string{"AGPL-3.0-only"}},
+		{"GPL-3.0-only OR MIT", LicenseAllowed, nil},
+		{"MIT AND GPL-3.0-or-later", LicenseDenied, []string{"GPL-3.0-or-later"}},
+		{"MPL-2.0", LicenseNotAllowed, []string{"MPL-2.0"}},
+		{"MPL-2.0 OR GPL-2.0-only", LicenseNotAllowed, []string{"MPL-2.0"}},
+		{"MPL-2.0 AND EPL-2.0", LicenseNotAllowed, []string{"MPL-2.0", "EPL-2.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expr, err := ParseLicenseExpression(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			status, offending := policy.Check(expr)
+			if status != tt.wantStatus {
+				t.Errorf("Check() status got = %v, want %v", status, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(offending, tt.wantOffending) {
+				t.Errorf("Check() offending got = %v, want %v", offending, tt.wantOffending)
+			}
+		})
+	}
+}
+
+func TestLicenseReportFromProject(t *testing.T) {
+	license := func(s string) *string { return &s }
+	project := &ProjectResponse{
+		Dependencies: []FullPackageInternal{
+			{Name: "lodash", Version: "4.17.21", Registry: "npm", License: license("MIT")},
+			{Name: "mysql", Version: "2.18.1", Registry: "npm", License: license("GPL-2.0-only")},
+			{Name: "internal", Version: "1.0.0", Registry: "npm"},
+			{Name: "odd", Version: "0.1.0", Registry: "npm", License: license("see LICENSE")},
+			{Name: "blob", Version: "0.0.1", Registry: "npm", License: license("LicenseRef-Custom")},
+		},
+	}
+	project.Stats.Licenses.Counts.Set("MIT", 1)
+	project.Stats.Licenses.Counts.Set("GPL-2.0-only", 1)
+
+	report := LicenseReportFromProject(project, &LicensePolicy{Deny: []string{"GPL-2.0-only"}})
+	if len(report.Packages) != 5 {
+		t.Errorf("LicenseReportFromProject() packages got = %v, want 5", len(report.Packages))
+	}
+
+	var problems []string
+	var statuses []LicenseStatus
+	for _, pkg := range report.Problems {
+		problems = append(problems, pkg.Package.Name)
+		statuses = append(statuses, pkg.Status)
+	}
+	wantProblems := []string{"mysql", "internal", "odd", "blob"}
+	wantStatuses := []LicenseStatus{LicenseDenied, LicenseMissing, LicenseInvalid, LicenseAllowed}
+	if !reflect.DeepEqual(problems, wantProblems) || !reflect.DeepEqual(statuses, wantStatuses) {
+		t.Errorf("LicenseReportFromProject() problems got = %v %v, want %v %v", problems, statuses, wantProblems, wantStatuses)
+	}
+
+	wantCategories := map[LicenseCategory]int{LicensePermissive: 1, LicenseStrongCopyleft: 1, LicenseUnknown: 3}
+	if !reflect.DeepEqual(report.Categories, wantCategories) {
+		t.Errorf("LicenseReportFromProject() categories got = %v, want %v", report.Categories, wantCategories)
+	}
+	if !reflect.DeepEqual(report.Counts, map[string]uint32{"MIT": 1, "GPL-2.0-only": 1}) {
+		t.Errorf("LicenseReportFromProject() counts got = %v", report.Counts)
+	}
+	if report.Pass() {
+		t.Errorf("Pass() got = true, want false")
+	}
+}
@@ -0,0 +1,231 @@
+package phylum
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PackageLocator is implemented by parsers for formats whose parse does not track lines, to find
+// the line declaring each package on demand
+type PackageLocator interface {
+	// Locate returns the 1-based line declaring each package found in data
+	Locate(data []byte) (map[PackageDescriptor]int, error)
+}
+
+// LocatePackages returns the line of lockfilePath that declares each package. Names are compared
+// the way the ecosystem does and versions must match exactly. Packages that cannot be found, and
+// lockfiles whose format does not track lines, are left out of the result.
+func LocatePackages(lockfilePath string, packages []PackageDescriptor) (map[PackageDescriptor]int, error) {
+	parser, err := DefaultParserRegistry.Detect(lockfilePath)
+	if err != nil {
+		return nil, fmt.Errorf("LocatePackages(): %w", err)
+	}
+	data, err := os.ReadFile(lockfilePath)
+	if err != nil {
+		return nil, fmt.Errorf("LocatePackages(): %w", err)
+	}
+
+	result, err := parser.Parse(lockfilePath, data, &ParseOptions{Offline: true})
+	if err != nil {
+		return nil, fmt.Errorf("LocatePackages(): %s parser: %w", parser.Name(), err)
+	}
+	lines := result.Lines
+	if locator, ok := parser.(PackageLocator); ok && lines == nil {
+		if lines, err = locator.Locate(data); err != nil {
+			return nil, fmt.Errorf("LocatePackages(): %s parser: %w", parser.Name(), err)
+		}
+	}
+
+	type locatedPackage struct {
+		key     packageKey
+		version string
+	}
+	declared := make(map[locatedPackage]int)
+	for pkg, line := range lines {
+		k := locatedPackage{diffPackageKey(pkg), pkg.Version}
+		if current, ok := declared[k]; !ok || line < current {
+			declared[k] = line
+		}
+	}
+
+	located := make(map[PackageDescriptor]int)
+	for _, pkg := range packages {
+		if line, ok := declared[locatedPackage{diffPackageKey(pkg), pkg.Version}]; ok {
+			located[pkg] = line
+		}
+	}
+	return located, nil
+}
+
+// jsonLines walks a JSON document token by token, tracking the line of the last token read
+type jsonLines struct {
+	dec    *json.Decoder
+	data   []byte
+	offset int
+	line   int
+}
+
+func newJSONLines(data []byte) *jsonLines {
+	return &jsonLines{dec: json.NewDecoder(bytes.NewReader(data)), data: data, line: 1}
+}
+
+// object calls fn with each key of the object that is the next value, and the line of the key.
+// fn must consume the value of the key. A null value is treated as an empty object.
+func (j *jsonLines) object(fn func(key string, line int) error) error {
+	tok, err := j.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("expected an object, got %v", tok)
+	}
+	for j.dec.More() {
+		tok, err := j.dec.Token()
+		if err != nil {
+			return err
+		}
+		end := int(j.dec.InputOffset())
+		j.line += bytes.Count(j.data[j.offset:end], []byte("\n"))
+		j.offset = end
+		if err := fn(tok.(string), j.line); err != nil {
+			return err
+		}
+	}
+	_, err = j.dec.Token()
+	return err
+}
+
+// decode consumes the next value into v
+func (j *jsonLines) decode(v interface{}) error {
+	return j.dec.Decode(v)
+}
+
+// skip consumes the next value
+func (j *jsonLines) skip() error {
+	var value json.RawMessage
+	return j.dec.Decode(&value)
+}
+
+// locateNpmLockfile finds the key of each package in the "packages" map of lockfile versions 2
+// and 3, or in the nested "dependencies" map of version 1
+func locateNpmLockfile(data []byte) (map[PackageDescriptor]int, error) {
+	lines := make(map[PackageDescriptor]int)
+	record := func(pkg PackageDescriptor, line int) {
+		if _, ok := lines[pkg]; !ok {
+			lines[pkg] = line
+		}
+	}
+
+	doc := newJSONLines(data)
+	var nested func() error
+	nested = func() error {
+		return doc.object(func(name string, line int) error {
+			var version string
+			var bundled bool
+			err := doc.object(func(field string, _ int) error {
+				switch field {
+				case "version":
+					return doc.decode(&version)
+				case "bundled":
+					return doc.decode(&bundled)
+				case "dependencies":
+					return nested()
+				}
+				return doc.skip()
+			})
+			if err != nil {
+				return err
+			}
+			if pkgName, pkgVersion, ok := npmDependencyVersion(name, version); ok && !bundled {
+				record(PackageDescriptor{Name: pkgName, Version: pkgVersion, Type: Npm}, line)
+			}
+			return nil
+		})
+	}
+
+	err := doc.object(func(key string, _ int) error {
+		switch key {
+		case "packages":
+			return doc.object(func(path string, line int) error {
+				var pkg npmLockfilePackage
+				if err := doc.decode(&pkg); err != nil {
+					return err
+				}
+				if descriptor, ok := npmLockedPackage(path, pkg); ok {
+					record(descriptor, line)
+				}
+				return nil
+			})
+		case "dependencies":
+			return nested()
+		}
+		return doc.skip()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse json: %w", err)
+	}
+	return lines, nil
+}
+
+// locatePipfileLock finds the key of each package in the default and develop sections
+func locatePipfileLock(data []byte) (map[PackageDescriptor]int, error) {
+	lines := make(map[PackageDescriptor]int)
+	doc := newJSONLines(data)
+	err := doc.object(func(section string, _ int) error {
+		if section != "default" && section != "develop" {
+			return doc.skip()
+		}
+		return doc.object(func(name string, line int) error {
+			var entry pipfileLockEntry
+			if err := doc.decode(&entry); err != nil {
+				return err
+			}
+			pkg := PackageDescriptor{Name: name, Version: strings.TrimPrefix(entry.Version, "=="), Type: Pypi}
+			if _, ok := lines[pkg]; !ok && pkg.Version != "" {
+				lines[pkg] = line
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse json: %w", err)
+	}
+	return lines, nil
+}
+
+// locateNugetLockfile finds the first key of each package across the target frameworks
+func locateNugetLockfile(data []byte) (map[PackageDescriptor]int, error) {
+	lines := make(map[PackageDescriptor]int)
+	doc := newJSONLines(data)
+	err := doc.object(func(key string, _ int) error {
+		if key != "dependencies" {
+			return doc.skip()
+		}
+		return doc.object(func(_ string, _ int) error {
+			return doc.object(func(name string, line int) error {
+				var entry nugetLockedEntry
+				if err := doc.decode(&entry); err != nil {
+					return err
+				}
+				if strings.EqualFold(entry.Type, "Project") || entry.Resolved == "" {
+					return nil
+				}
+				pkg := PackageDescriptor{Name: name, Version: entry.Resolved, Type: Nuget}
+				if current, ok := lines[pkg]; !ok || line < current {
+					lines[pkg] = line
+				}
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse json: %w", err)
+	}
+	return lines, nil
+}
@@ -0,0 +1,110 @@
+package phylum
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLocatePackages(t *testing.T) {
+	dir := t.TempDir()
+	nugetLock := filepath.Join(dir, "packages.lock.json")
+	writeFile(t, nugetLock, `{
+  "version": 1,
+  "dependencies": {
+    "net6.0": {
+      "Newtonsoft.Json": {
+        "type": "Direct",
+        "resolved": "13.0.1"
+      },
+      "App.Core": {
+        "type": "Project"
+      }
+    }
+  }
+}
+`)
+	nestedRequirements := filepath.Join(dir, "requirements.txt")
+	writeFile(t, filepath.Join(dir, "base.txt"), "six==1.16.0\n")
+	writeFile(t, nestedRequirements, "-r base.txt\n\nrequests==2.28.1\n")
+
+	tests := []struct {
+		lockfile string
+		pkg      PackageDescriptor
+		wantLine int
+	}{
+		{"package-lock.json", PackageDescriptor{Name: "accepts", Version: "1.3.8", Type: Npm}, 35},
+		{"package-lock-v6.json", PackageDescriptor{Name: "ansi-regex", Version: "5.0.0", Type: Npm}, 12},
+		{"yarn.lock", PackageDescriptor{Name: "body-parser", Version: "1.19.2", Type: Npm}, 25},
+		{"Pipfile.lock", PackageDescriptor{Name: "six", Version: "1.11.0", Type: Pypi}, 254},
+		{"requirements.txt", PackageDescriptor{Name: "django", Version: "4.0.4", Type: Pypi}, 2},
+		{"Gemfile.lock", PackageDescriptor{Name: "rails", Version: "5.1.6", Type: Rubygems}, 417},
+		{"gradle.lockfile", PackageDescriptor{Name: "org.springframework:spring-core", Version: "5.2.15.RELEASE", Type: Maven}, 9},
+		{"effective-pom.xml", PackageDescriptor{Name: "net.sf.bluecove:bluecove", Version: "2.1.1-SNAPSHOT", Type: Maven}, 32},
+		{"sample.csproj", PackageDescriptor{Name: "NUnit3TestAdapter", Version: "3.13.0", Type: Nuget}, 15},
+		{nugetLock, PackageDescriptor{Name: "newtonsoft.json", Version: "13.0.1", Type: Nuget}, 5},
+		{nestedRequirements, PackageDescriptor{Name: "requests", Version: "2.28.1", Type: Pypi}, 3},
+
+		// Packages are never placed on a declaration of another version, group or file
+		{"package-lock.json", PackageDescriptor{Name: "not-a-dependency", Version: "1.0.0", Type: Npm}, 0},
+		{"yarn.lock", PackageDescriptor{Name: "body-parser", Version: "1.0.0", Type: Npm}, 0},
+		{"effective-pom.xml", PackageDescriptor{Name: "com.intel:bluecove", Version: "2.1.1-SNAPSHOT", Type: Maven}, 0},
+		{nestedRequirements, PackageDescriptor{Name: "six", Version: "1.16.0", Type: Pypi}, 0},
+
+		// TOML formats don't track lines
+		{"poetry.lock", PackageDescriptor{Name: "CacheControl", Version: "0.12.10", Type: Pypi}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.lockfile)+"/"+tt.pkg.Name+"@"+tt.pkg.Version, func(t *testing.T) {
+			lockfile := tt.lockfile
+			if !filepath.IsAbs(lockfile) {
+				lockfile = filepath.Join("test_lockfiles", lockfile)
+			}
+			lines, err := LocatePackages(lockfile, []PackageDescriptor{tt.pkg})
+			if err != nil {
+				t.Fatalf("LocatePackages() error = %v", err)
+			}
+			if got := lines[tt.pkg]; got != tt.wantLine {
+				t.Errorf("LocatePackages() line got = %v, want %v", got, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestParserRegistry_ParseLines(t *testing.T) {
+	for _, lockfile := range []string{"yarn-v1.lock", "yarn.lock", "requirements.txt", "Gemfile.lock", "gradle.lockfile", "workspace-effective-pom.xml", "Calculator.csproj"} {
+		t.Run(lockfile, func(t *testing.T) {
+			result, err := NewParserRegistry().Parse(filepath.Join("test_lockfiles", lockfile), &ParseOptions{Offline: true})
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			for _, pkg := range result.Packages {
+				if result.Lines[pkg] == 0 {
+					t.Errorf("Parse() has no line for %+v", pkg)
+				}
+			}
+		})
+	}
+
+	// JSON lockfiles are only located on demand
+	for _, lockfile := range []string{"package-lock.json", "package-lock-v6.json", "Pipfile.lock"} {
+		t.Run(lockfile, func(t *testing.T) {
+			path := filepath.Join("test_lockfiles", lockfile)
+			result, err := NewParserRegistry().Parse(path, &ParseOptions{Offline: true})
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if result.Lines != nil {
+				t.Errorf("Parse() Lines got = %v entries, want nil", len(result.Lines))
+			}
+			lines, err := LocatePackages(path, result.Packages)
+			if err != nil {
+				t.Fatalf("LocatePackages() error = %v", err)
+			}
+			for _, pkg := range result.Packages {
+				if lines[pkg] == 0 {
+					t.Errorf("LocatePackages() has no line for %+v", pkg)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package phylum
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ChangeKind classifies a package change between two lockfile revisions
+type ChangeKind string
+
+const (
+	ChangeAdd       ChangeKind = "add"
+	ChangeRemove    ChangeKind = "remove"
+	ChangeUpgrade   ChangeKind = "upgrade"
+	ChangeDowngrade ChangeKind = "downgrade"
+	ChangeMajor     ChangeKind = "major"   // An upgrade to a new major version
+	ChangeRespell   ChangeKind = "respell" // Another spelling of the same version, such as 1.0 and 1.0.0
+)
+
+// PackageChange is a package added, removed or moved to another version
+type PackageChange struct {
+	Kind ChangeKind
+	Type PackageType
+	Name string
+	From string // Empty when the package was added
+	To   string // Empty when the package was removed
+}
+
+// Package returns the package as it is after the change, or before it when it was removed
+func (c PackageChange) Package() PackageDescriptor {
+	if c.Kind == ChangeRemove {
+		return PackageDescriptor{Name: c.Name, Version: c.From, Type: c.Type}
+	}
+	return PackageDescriptor{Name: c.Name, Version: c.To, Type: c.Type}
+}
+
+// ClassifyVersionChange compares two versions of a package using the ordering of its
+// ecosystem. An upgrade that raises the first non-zero release segment is a major bump, so
+// 1.x to 2.x and 0.3.x to 0.4.x are both major. Different strings that are equal in the
+// ecosystem, such as 1.0 and 1.0.0, are a respelling, and identical strings return an empty
+// ChangeKind.
+func ClassifyVersionChange(pkgType PackageType, from string, to string) ChangeKind {
+	switch c := CompareEcosystemVersions(pkgType, from, to); {
+	case from == to:
+		return ""
+	case c > 0:
+		return ChangeDowngrade
+	case c == 0:
+		return ChangeRespell
+	}
+
+	fromPos, fromMajor := majorVersion(from)
+	toPos, toMajor := majorVersion(to)
+	if fromPos != toPos || fromMajor != toMajor {
+		return ChangeMajor
+	}
+	return ChangeUpgrade
+}
+
+// DiffPackages compares two sets of packages, such as two revisions of a lockfile, without
+// analysing them. Packages are matched by ecosystem and name, using PEP 503 names for pypi and
+// case-insensitive names for NuGet. A version whose string changed but which the ecosystem
+// treats as the same version, such as 1.0.0.Final and 1.0.0 in Maven, is reported as a
+// ChangeRespell. Changes are sorted by ecosystem and name.
+func DiffPackages(base []PackageDescriptor, head []PackageDescriptor) []PackageChange {
+	var changes []PackageChange
+	added, removed, changed := diffPackageVersions(base, head)
+	for _, pkg := range removed {
+		changes = append(changes, PackageChange{Kind: ChangeRemove, Type: pkg.Type, Name: pkg.Name, From: pkg.Version})
+	}
+	for _, pkg := range added {
+		changes = append(changes, PackageChange{Kind: ChangeAdd, Type: pkg.Type, Name: pkg.Name, To: pkg.Version})
+	}
+	for _, pair := range changed {
+		kind := ClassifyVersionChange(pair[1].Type, pair[0].Version, pair[1].Version)
+		changes = append(changes, PackageChange{Kind: kind, Type: pair[1].Type, Name: pair[1].Name, From: pair[0].Version, To: pair[1].Version})
+	}
+	sortPackageChanges(changes)
+	return changes
+}
+
+// DiffLockfiles parses two revisions of a lockfile offline and compares their packages
+func DiffLockfiles(basePath string, headPath string) ([]PackageChange, error) {
+	base, err := ParseLockfileOffline(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("DiffLockfiles(): %w", err)
+	}
+	head, err := ParseLockfileOffline(headPath)
+	if err != nil {
+		return nil, fmt.Errorf("DiffLockfiles(): %w", err)
+	}
+	return DiffPackages(base, head), nil
+}
+
+// ChangedPackages returns the packages to analyse for a quick verdict on a set of changes: those
+// added or moved to another version. Removed and respelled packages cannot add risk and are
+// left out.
+func ChangedPackages(changes []PackageChange) []PackageDescriptor {
+	var packages []PackageDescriptor
+	for _, change := range changes {
+		if change.Kind != ChangeRemove && change.Kind != ChangeRespell {
+			packages = append(packages, change.Package())
+		}
+	}
+	return packages
+}
+
+func sortPackageChanges(changes []PackageChange) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return CompareEcosystemVersions(a.Type, a.Package().Version, b.Package().Version) < 0
+	})
+}
@@ -0,0 +1,98 @@
+package phylum
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifyVersionChange(t *testing.T) {
+	tests := []struct {
+		pkgType  PackageType
+		from, to string
+		want     ChangeKind
+	}{
+		{Npm, "4.17.20", "4.17.21", ChangeUpgrade},
+		{Npm, "4.17.21", "4.17.20", ChangeDowngrade},
+		{Npm, "4.18.2", "5.0.0", ChangeMajor},
+		{Npm, "0.3.1", "0.4.0", ChangeMajor},
+		{Npm, "0.3.1", "0.3.2", ChangeUpgrade},
+		{Npm, "5.0.0-beta.1", "5.0.0", ChangeUpgrade},
+		{Pypi, "1.0", "1.0.0", ChangeRespell},
+		{Maven, "1.0.0.Final", "1.0.0", ChangeRespell},
+		{Npm, "1.0.0", "1.0.0", ""},
+		{Pypi, "2.0rc1", "2.0", ChangeUpgrade},
+		{Maven, "1.0-SNAPSHOT", "1.0", ChangeUpgrade},
+		{Rubygems, "6.1.7", "7.0.4", ChangeMajor},
+	}
+	for _, tt := range tests {
+		if got := ClassifyVersionChange(tt.pkgType, tt.from, tt.to); got != tt.want {
+			t.Errorf("ClassifyVersionChange(%v, %q, %q) got = %q, want %q", tt.pkgType, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestDiffPackages(t *testing.T) {
+	base := []PackageDescriptor{
+		{Name: "express", Version: "4.18.2", Type: Npm},
+		{Name: "left-pad", Version: "1.3.0", Type: Npm},
+		{Name: "qs", Version: "6.11.1", Type: Npm},
+		{Name: "Django", Version: "3.2.18", Type: Pypi},
+		{Name: "zope.interface", Version: "5.0", Type: Pypi},
+		{Name: "Newtonsoft.Json", Version: "13.0.1", Type: Nuget},
+		{Name: "org.hibernate:hibernate-core", Version: "5.6.15.Final", Type: Maven},
+	}
+	head := []PackageDescriptor{
+		{Name: "express", Version: "5.0.0", Type: Npm},
+		{Name: "qs", Version: "6.11.0", Type: Npm},
+		{Name: "chalk", Version: "5.2.0", Type: Npm},
+		{Name: "django", Version: "3.2.19", Type: Pypi},
+		{Name: "zope-interface", Version: "5.0.0", Type: Pypi},
+		{Name: "newtonsoft.json", Version: "13.0.1", Type: Nuget},
+		{Name: "org.hibernate:hibernate-core", Version: "5.6.15", Type: Maven},
+	}
+	want := []PackageChange{
+		{Kind: ChangeRespell, Type: Maven, Name: "org.hibernate:hibernate-core", From: "5.6.15.Final", To: "5.6.15"},
+		{Kind: ChangeAdd, Type: Npm, Name: "chalk", To: "5.2.0"},
+		{Kind: ChangeMajor, Type: Npm, Name: "express", From: "4.18.2", To: "5.0.0"},
+		{Kind: ChangeRemove, Type: Npm, Name: "left-pad", From: "1.3.0"},
+		{Kind: ChangeDowngrade, Type: Npm, Name: "qs", From: "6.11.1", To: "6.11.0"},
+		{Kind: ChangeUpgrade, Type: Pypi, Name: "django", From: "3.2.18", To: "3.2.19"},
+		{Kind: ChangeRespell, Type: Pypi, Name: "zope-interface", From: "5.0", To: "5.0.0"},
+	}
+	got := DiffPackages(base, head)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffPackages() got = %+v, want %+v", got, want)
+	}
+
+	wantSubmit := []PackageDescriptor{
+		{Name: "chalk", Version: "5.2.0", Type: Npm},
+		{Name: "express", Version: "5.0.0", Type: Npm},
+		{Name: "qs", Version: "6.11.0", Type: Npm},
+		{Name: "django", Version: "3.2.19", Type: Pypi},
+	}
+	if submit := ChangedPackages(got); !reflect.DeepEqual(submit, wantSubmit) {
+		t.Errorf("ChangedPackages() got = %v, want %v", submit, wantSubmit)
+	}
+}
+
+func TestDiffLockfiles(t *testing.T) {
+	changes, err := DiffLockfiles("test_lockfiles/yarn-v1.lock", "test_lockfiles/yarn-v1.trailing_newlines.lock")
+	if err != nil {
+		t.Fatalf("DiffLockfiles() error = %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("DiffLockfiles() of equivalent lockfiles got = %+v", changes)
+	}
+
+	changes, err = DiffLockfiles("test_lockfiles/yarn-v1.simple.lock", "test_lockfiles/yarn-v1.lock")
+	if err != nil {
+		t.Fatalf("DiffLockfiles() error = %v", err)
+	}
+	if len(ChangedPackages(changes)) == 0 {
+		t.Errorf("DiffLockfiles() got no changed packages")
+	}
+
+	if _, err := DiffLockfiles("test_lockfiles/does-not-exist.json", "test_lockfiles/yarn-v1.lock"); err == nil {
+		t.Errorf("DiffLockfiles() error = nil, want error for a missing lockfile")
+	}
+}
@@ -0,0 +1,114 @@
+package phylum
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ParseOptions controls how lockfiles are parsed
+type ParseOptions struct {
+	Offline        bool // Parse locally without contacting the Phylum parse service
+	ProductionOnly bool // Skip test and provided scoped dependencies where the format records them
+
+	Configurations        []string // Gradle configurations to include, all when empty
+	ExcludeConfigurations []string // Gradle configurations to exclude
+
+	AllowUnpinned bool // Return the pinned packages without an error when some requirements are unpinned
+}
+
+// UnpinnedRequirement is a declared dependency whose specifier does not pin a single version
+type UnpinnedRequirement struct {
+	Name      string
+	Specifier string
+	File      string
+	Line      int
+}
+
+// ParseResult is the detailed output of parsers for manifests that can declare dependencies
+// without resolving them to a version
+type ParseResult struct {
+	Packages []PackageDescriptor
+	Unpinned []UnpinnedRequirement     // Dependencies declared without an exact version
+	Warnings []string                  // Entries that were skipped and why
+	Lines    map[PackageDescriptor]int // 1-based line declaring each package, for formats whose parse tracks lines
+}
+
+// UnpinnedRequirementsError is returned along with the pinned packages of a manifest that also
+// declares dependencies without an exact version. Those dependencies are not in the packages, so
+// analysing only the packages covers part of the manifest.
+type UnpinnedRequirementsError struct {
+	Path     string
+	Unpinned []UnpinnedRequirement
+}
+
+func (e *UnpinnedRequirementsError) Error() string {
+	var names []string
+	for i, req := range e.Unpinned {
+		if i == 5 {
+			names = append(names, fmt.Sprintf("and %d more", len(e.Unpinned)-i))
+			break
+		}
+		names = append(names, req.Name)
+	}
+	return fmt.Sprintf("%v: %d requirements are not pinned to a version: %s", e.Path, len(e.Unpinned), strings.Join(names, ", "))
+}
+
+// LockfileSyntaxError reports a malformed lockfile along with the 1-based position of the problem
+type LockfileSyntaxError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *LockfileSyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// ParseLockfileWithOptions parses a lockfile into packages that can be submitted for analysis.
+// Local parsers are tried first. When none recognizes the lockfile it is uploaded to the Phylum
+// parse service, unless opts.Offline is set in which case ErrNoLockfileParser is returned. Maven
+// effective POMs are always uploaded unless opts.Offline is set, as only the parse service
+// resolves their transitive dependencies. When the manifest declares requirements without an
+// exact version, the pinned packages are returned with an *UnpinnedRequirementsError unless
+// opts.AllowUnpinned is set.
+func (p *PhylumClient) ParseLockfileWithOptions(lockfilePath string, opts *ParseOptions) (*[]PackageDescriptor, error) {
+	if opts == nil {
+		opts = &ParseOptions{}
+	}
+
+	registry := p.Parsers
+	if registry == nil {
+		registry = DefaultParserRegistry
+	}
+
+	parser, err := registry.Detect(lockfilePath)
+	if !opts.Offline && (errors.Is(err, ErrNoLockfileParser) || err == nil && parser.Name() == "maven") {
+		return p.parseLockfileRemote(lockfilePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result, err := parseWith(parser, lockfilePath, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Unpinned) > 0 && !opts.AllowUnpinned {
+		return &result.Packages, &UnpinnedRequirementsError{Path: lockfilePath, Unpinned: result.Unpinned}
+	}
+	return &result.Packages, nil
+}
+
+// ParseLockfileOffline parses a lockfile locally with the parsers in DefaultParserRegistry.
+// Requirements that are not pinned to a single version are not included in the packages and are
+// reported with an *UnpinnedRequirementsError, returned along with the pinned packages.
+func ParseLockfileOffline(lockfilePath string) ([]PackageDescriptor, error) {
+	result, err := DefaultParserRegistry.Parse(lockfilePath, &ParseOptions{Offline: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Unpinned) > 0 {
+		return result.Packages, &UnpinnedRequirementsError{Path: lockfilePath, Unpinned: result.Unpinned}
+	}
+	return result.Packages, nil
+}
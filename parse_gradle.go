@@ -0,0 +1,97 @@
+package phylum
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// GradleParseOptions selects which Gradle configurations contribute packages
+type GradleParseOptions struct {
+	Configurations        []string // Only include entries locked for one of these configurations, all when empty
+	ExcludeConfigurations []string // Drop configurations by name, applied after Configurations
+	ProductionOnly        bool     // Drop configurations whose name starts with "test"
+}
+
+// ParseGradleLockfile parses the contents of a gradle.lockfile. Lines take one of three shapes:
+// "group:artifact:version=conf1,conf2", a bare "group:artifact:version" with no configuration, and
+// the "empty=conf1,conf2" sentinel listing configurations without dependencies. An entry is kept
+// when at least one of its configurations passes the filters in opts. Bare entries are kept unless
+// opts.Configurations restricts the set of configurations.
+func ParseGradleLockfile(data []byte, opts *GradleParseOptions) ([]PackageDescriptor, error) {
+	result, err := parseGradleLockfile(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	return result.Packages, nil
+}
+
+// parseGradleLockfile parses a gradle.lockfile, recording the line locking each package
+func parseGradleLockfile(data []byte, opts *GradleParseOptions) (*ParseResult, error) {
+	if opts == nil {
+		opts = &GradleParseOptions{}
+	}
+
+	result := &ParseResult{Lines: make(map[PackageDescriptor]int)}
+	lineNum := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		coordinates, configurations, _ := strings.Cut(line, "=")
+		if coordinates == "empty" {
+			continue
+		}
+
+		parts := strings.Split(coordinates, ":")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, &LockfileSyntaxError{Line: lineNum, Column: 1, Msg: fmt.Sprintf("expected group:artifact:version, got %q", coordinates)}
+		}
+
+		var confs []string
+		for _, conf := range strings.Split(configurations, ",") {
+			if conf = strings.TrimSpace(conf); conf != "" {
+				confs = append(confs, conf)
+			}
+		}
+		if !opts.keep(confs) {
+			continue
+		}
+
+		pkg := PackageDescriptor{Name: parts[0] + ":" + parts[1], Version: parts[2], Type: Maven}
+		result.Packages = append(result.Packages, pkg)
+		result.Lines[pkg] = lineNum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ParseGradleLockfile(): failed to read lockfile: %w", err)
+	}
+
+	return result, nil
+}
+
+// keep reports whether an entry locked for confs passes the configuration filters
+func (o *GradleParseOptions) keep(confs []string) bool {
+	if len(confs) == 0 {
+		return len(o.Configurations) == 0
+	}
+
+	for _, conf := range confs {
+		if len(o.Configurations) > 0 && !containsString(o.Configurations, conf) {
+			continue
+		}
+		if containsString(o.ExcludeConfigurations, conf) {
+			continue
+		}
+		if o.ProductionOnly && strings.HasPrefix(conf, "test") {
+			continue
+		}
+		return true
+	}
+	return false
+}
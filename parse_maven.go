@@ -0,0 +1,213 @@
+package phylum
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+type mavenProjects struct {
+	Projects []mavenProject `xml:"project"`
+}
+
+type mavenProject struct {
+	GroupId    string `xml:"groupId"`
+	ArtifactId string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Parent     struct {
+		GroupId    string `xml:"groupId"`
+		ArtifactId string `xml:"artifactId"`
+		Version    string `xml:"version"`
+	} `xml:"parent"`
+	Properties   mavenProperties   `xml:"properties"`
+	Dependencies []mavenDependency `xml:"dependencies>dependency"`
+}
+
+type mavenDependency struct {
+	GroupId    string `xml:"groupId"`
+	ArtifactId string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Scope      string `xml:"scope"`
+	Line       int    `xml:"-"`
+}
+
+// UnmarshalXML records the line of the <dependency> element
+func (m *mavenDependency) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain mavenDependency
+	line, _ := d.InputPos()
+	if err := d.DecodeElement((*plain)(m), &start); err != nil {
+		return err
+	}
+	m.Line = line
+	return nil
+}
+
+// mavenProperties collects the arbitrary child elements of <properties>
+type mavenProperties map[string]string
+
+func (m *mavenProperties) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*m = mavenProperties{}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var value string
+			if err := d.DecodeElement(&value, &t); err != nil {
+				return err
+			}
+			(*m)[t.Name.Local] = strings.TrimSpace(value)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+var mavenPropertyPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// ParseMavenEffectivePom parses the output of "mvn help:effective-pom", either a single <project>
+// or a multi-module <projects> document. Only the dependencies the projects declare are returned:
+// an effective POM doesn't list transitive dependencies, which the Phylum parse service resolves.
+// Dependencies are named groupId:artifactId and ${property} placeholders are resolved from the
+// declaring project's <properties>. Dependencies on other modules of the same workspace are
+// skipped, and when productionOnly is set test and provided scoped dependencies are excluded.
+// Placeholders that cannot be resolved are reported as warnings.
+func ParseMavenEffectivePom(data []byte, productionOnly bool) (*ParseResult, error) {
+	projects, err := decodeMavenProjects(data)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := make(map[string]bool)
+	for _, project := range projects {
+		modules[project.GroupId+":"+project.ArtifactId] = true
+	}
+
+	result := &ParseResult{Lines: make(map[PackageDescriptor]int)}
+	for _, project := range projects {
+		properties := project.properties()
+		for _, dep := range project.Dependencies {
+			scope := strings.TrimSpace(dep.Scope)
+			if productionOnly && (scope == "test" || scope == "provided") {
+				continue
+			}
+
+			coordinates := []string{dep.GroupId, dep.ArtifactId, dep.Version}
+			var resolveErr error
+			for i := range coordinates {
+				if coordinates[i], resolveErr = resolveMavenProperties(coordinates[i], properties); resolveErr != nil {
+					break
+				}
+			}
+			if resolveErr != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s:%s: %v", project.ArtifactId, dep.GroupId, dep.ArtifactId, resolveErr))
+				continue
+			}
+			groupId, artifactId, version := coordinates[0], coordinates[1], coordinates[2]
+			if version == "" {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s:%s has no version", project.ArtifactId, groupId, artifactId))
+				continue
+			}
+
+			name := groupId + ":" + artifactId
+			if modules[name] {
+				continue
+			}
+			pkg := PackageDescriptor{Name: name, Version: version, Type: Maven}
+			if _, seen := result.Lines[pkg]; seen {
+				continue
+			}
+			result.Lines[pkg] = dep.Line
+			result.Packages = append(result.Packages, pkg)
+		}
+	}
+
+	return result, nil
+}
+
+// decodeMavenProjects decodes either a <project> or a <projects> root element
+func decodeMavenProjects(data []byte) ([]mavenProject, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("ParseMavenEffectivePom(): no <project> element found")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ParseMavenEffectivePom(): failed to parse xml: %w", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "projects":
+			var projects mavenProjects
+			if err := decoder.DecodeElement(&projects, &start); err != nil {
+				return nil, fmt.Errorf("ParseMavenEffectivePom(): failed to parse xml: %w", err)
+			}
+			return projects.Projects, nil
+		case "project":
+			var project mavenProject
+			if err := decoder.DecodeElement(&project, &start); err != nil {
+				return nil, fmt.Errorf("ParseMavenEffectivePom(): failed to parse xml: %w", err)
+			}
+			return []mavenProject{project}, nil
+		default:
+			return nil, fmt.Errorf("ParseMavenEffectivePom(): unexpected root element <%s>", start.Name.Local)
+		}
+	}
+}
+
+// properties returns the project properties along with the built-in project.* coordinates
+func (m mavenProject) properties() map[string]string {
+	properties := map[string]string{
+		"project.groupId":           m.GroupId,
+		"project.artifactId":        m.ArtifactId,
+		"project.version":           m.Version,
+		"project.parent.groupId":    m.Parent.GroupId,
+		"project.parent.artifactId": m.Parent.ArtifactId,
+		"project.parent.version":    m.Parent.Version,
+	}
+	if properties["project.groupId"] == "" {
+		properties["project.groupId"] = m.Parent.GroupId
+	}
+	if properties["project.version"] == "" {
+		properties["project.version"] = m.Parent.Version
+	}
+	for k, v := range m.Properties {
+		properties[k] = v
+	}
+	return properties
+}
+
+// resolveMavenProperties substitutes ${property} placeholders, following nested references
+func resolveMavenProperties(value string, properties map[string]string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	// Bound the expansion depth so self-referencing properties can't loop forever
+	for i := 0; i < 10 && strings.Contains(value, "${"); i++ {
+		var missing []string
+		value = mavenPropertyPattern.ReplaceAllStringFunc(value, func(placeholder string) string {
+			key := placeholder[2 : len(placeholder)-1]
+			if resolved, ok := properties[key]; ok && resolved != "" {
+				return resolved
+			}
+			missing = append(missing, key)
+			return placeholder
+		})
+		if len(missing) > 0 {
+			return "", fmt.Errorf("unresolved property ${%s}", strings.Join(missing, "}, ${"))
+		}
+	}
+	if strings.Contains(value, "${") {
+		return "", fmt.Errorf("property expansion did not terminate for %s", value)
+	}
+	return value, nil
+}
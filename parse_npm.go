@@ -0,0 +1,246 @@
+package phylum
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type npmLockfile struct {
+	LockfileVersion int                              `json:"lockfileVersion"`
+	Packages        map[string]npmLockfilePackage    `json:"packages"`
+	Dependencies    map[string]npmLockfileDependency `json:"dependencies"`
+}
+
+// npmLockfilePackage is an entry of the "packages" map used by lockfile versions 2 and 3
+type npmLockfilePackage struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Resolved string `json:"resolved"`
+	Link     bool   `json:"link"`
+	InBundle bool   `json:"inBundle"`
+
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+// npmLockfileDependency is an entry of the nested "dependencies" map used by lockfile version 1
+type npmLockfileDependency struct {
+	Version      string                           `json:"version"`
+	Bundled      bool                             `json:"bundled"`
+	Requires     map[string]string                `json:"requires"`
+	Dependencies map[string]npmLockfileDependency `json:"dependencies"`
+}
+
+// ParseNpmLockfile parses the contents of a package-lock.json or npm-shrinkwrap.json.
+// Lockfile versions 2 and 3 are read from the "packages" map, version 1 from the nested
+// "dependencies" map. Workspace links and bundled dependencies are skipped.
+func ParseNpmLockfile(data []byte) ([]PackageDescriptor, error) {
+	var lockfile npmLockfile
+	if err := json.Unmarshal(data, &lockfile); err != nil {
+		return nil, fmt.Errorf("ParseNpmLockfile(): failed to parse json: %w", err)
+	}
+
+	if lockfile.Packages != nil {
+		return parseNpmPackages(lockfile.Packages), nil
+	}
+	if lockfile.Dependencies != nil {
+		return parseNpmDependencies(lockfile.Dependencies), nil
+	}
+	return nil, fmt.Errorf("ParseNpmLockfile(): lockfile has neither packages nor dependencies")
+}
+
+func parseNpmPackages(packages map[string]npmLockfilePackage) []PackageDescriptor {
+	var result []PackageDescriptor
+
+	for _, path := range sortedKeys(packages) {
+		if pkg, ok := npmLockedPackage(path, packages[path]); ok {
+			result = append(result, pkg)
+		}
+	}
+	return result
+}
+
+// npmLockedPackage returns the package installed at path, skipping the root project, workspace
+// sources, links and bundled dependencies
+func npmLockedPackage(path string, pkg npmLockfilePackage) (PackageDescriptor, bool) {
+	// The root project and workspace sources live outside node_modules
+	if !strings.Contains(path, "node_modules/") {
+		return PackageDescriptor{}, false
+	}
+	if pkg.Link || pkg.InBundle || pkg.Version == "" {
+		return PackageDescriptor{}, false
+	}
+	return npmPackageDescriptor(path, pkg), true
+}
+
+func npmPackageDescriptor(path string, pkg npmLockfilePackage) PackageDescriptor {
+	name := pkg.Name
+	if name == "" {
+		name = path[strings.LastIndex(path, "node_modules/")+len("node_modules/"):]
+	}
+	return PackageDescriptor{Name: name, Version: pkg.Version, Type: Npm}
+}
+
+func parseNpmDependencies(dependencies map[string]npmLockfileDependency) []PackageDescriptor {
+	var result []PackageDescriptor
+
+	for _, name := range sortedKeys(dependencies) {
+		dep := dependencies[name]
+		if dep.Bundled {
+			continue
+		}
+
+		if pkgName, version, ok := npmDependencyVersion(name, dep.Version); ok {
+			result = append(result, PackageDescriptor{Name: pkgName, Version: version, Type: Npm})
+		}
+		result = append(result, parseNpmDependencies(dep.Dependencies)...)
+	}
+	return result
+}
+
+// npmDependencyVersion resolves a v1 dependency version, following "npm:" aliases and
+// rejecting local "file:" and "link:" references
+func npmDependencyVersion(name string, version string) (string, string, bool) {
+	switch {
+	case version == "":
+		return "", "", false
+	case strings.HasPrefix(version, "file:"), strings.HasPrefix(version, "link:"):
+		return "", "", false
+	case strings.HasPrefix(version, "npm:"):
+		alias := strings.TrimPrefix(version, "npm:")
+		at := strings.LastIndex(alias, "@")
+		if at <= 0 {
+			return "", "", false
+		}
+		return alias[:at], alias[at+1:], true
+	}
+	return name, version, true
+}
+
+// NpmDependencyGraph builds a dependency graph from the contents of a package-lock.json. For lockfile
+// versions 2 and 3 dependencies are resolved the way node does, searching node_modules directories
+// from the dependent package up to the root, and the dependencies of the root project and its
+// workspaces are direct. Version 1 lockfiles don't record the root's dependencies, so packages
+// nothing else requires are treated as direct.
+func NpmDependencyGraph(data []byte) (*DependencyGraph, error) {
+	var lockfile npmLockfile
+	if err := json.Unmarshal(data, &lockfile); err != nil {
+		return nil, fmt.Errorf("NpmDependencyGraph(): failed to parse json: %w", err)
+	}
+
+	switch {
+	case lockfile.Packages != nil:
+		return npmPackagesGraph(lockfile.Packages), nil
+	case lockfile.Dependencies != nil:
+		g := NewDependencyGraph()
+		npmDependenciesGraph(g, []map[string]npmLockfileDependency{lockfile.Dependencies})
+		g.markRoots()
+		return g, nil
+	}
+	return nil, fmt.Errorf("NpmDependencyGraph(): lockfile has neither packages nor dependencies")
+}
+
+func npmPackagesGraph(packages map[string]npmLockfilePackage) *DependencyGraph {
+	g := NewDependencyGraph()
+
+	// resolve returns the installed package a dependency of the package at path refers to
+	resolve := func(path string, name string) (PackageDescriptor, bool) {
+		dir := path
+		for {
+			candidate := "node_modules/" + name
+			if dir != "" {
+				candidate = dir + "/" + candidate
+			}
+			if pkg, ok := packages[candidate]; ok {
+				if pkg.Link {
+					// Links point at workspace sources, which aren't packages
+					return PackageDescriptor{}, false
+				}
+				if pkg.InBundle || pkg.Version == "" {
+					return PackageDescriptor{}, false
+				}
+				return npmPackageDescriptor(candidate, pkg), true
+			}
+			if dir == "" {
+				return PackageDescriptor{}, false
+			}
+			if idx := strings.LastIndex(dir, "/node_modules/"); idx >= 0 {
+				dir = dir[:idx]
+			} else {
+				dir = ""
+			}
+		}
+	}
+
+	for _, path := range sortedKeys(packages) {
+		pkg := packages[path]
+		isProject := !strings.Contains(path, "node_modules/")
+		if !isProject && (pkg.Link || pkg.InBundle || pkg.Version == "") {
+			continue
+		}
+
+		var parent PackageDescriptor
+		if !isProject {
+			parent = npmPackageDescriptor(path, pkg)
+			g.AddPackage(parent, false)
+		}
+
+		// Dev dependencies are only installed for the root project and workspaces
+		groups := []map[string]string{pkg.Dependencies, pkg.OptionalDependencies, pkg.PeerDependencies}
+		if isProject {
+			groups = append(groups, pkg.DevDependencies)
+		}
+		for _, deps := range groups {
+			for _, name := range sortedKeys(deps) {
+				child, ok := resolve(path, name)
+				if !ok {
+					continue
+				}
+				if isProject {
+					g.AddPackage(child, true)
+				} else {
+					g.AddEdge(parent, child)
+				}
+			}
+		}
+	}
+	return g
+}
+
+// npmDependenciesGraph adds the nested v1 dependencies in the innermost scope to g. Requirements
+// are resolved from the innermost scope outwards, like node_modules lookups.
+func npmDependenciesGraph(g *DependencyGraph, scopes []map[string]npmLockfileDependency) {
+	dependencies := scopes[len(scopes)-1]
+	for _, name := range sortedKeys(dependencies) {
+		dep := dependencies[name]
+		if dep.Bundled {
+			continue
+		}
+		pkgName, version, ok := npmDependencyVersion(name, dep.Version)
+		if !ok {
+			continue
+		}
+		parent := PackageDescriptor{Name: pkgName, Version: version, Type: Npm}
+		g.AddPackage(parent, false)
+
+		inner := append(scopes[:len(scopes):len(scopes)], dep.Dependencies)
+		for _, required := range sortedKeys(dep.Requires) {
+			for i := len(inner) - 1; i >= 0; i-- {
+				target, ok := inner[i][required]
+				if !ok {
+					continue
+				}
+				if childName, childVersion, ok := npmDependencyVersion(required, target.Version); ok && !target.Bundled {
+					g.AddEdge(parent, PackageDescriptor{Name: childName, Version: childVersion, Type: Npm})
+				}
+				break
+			}
+		}
+		if len(dep.Dependencies) > 0 {
+			npmDependenciesGraph(g, inner)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package phylum
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// msbuildPackageReference is a <PackageReference> or <PackageVersion> item. Attribute names are
+// matched case-insensitively since MSBuild accepts both Version and version.
+type msbuildPackageReference struct {
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Version string     `xml:"Version"`
+	Line    int        `xml:"-"`
+}
+
+func (r msbuildPackageReference) attr(name string) string {
+	for _, attr := range r.Attrs {
+		if strings.EqualFold(attr.Name.Local, name) {
+			return strings.TrimSpace(attr.Value)
+		}
+	}
+	return ""
+}
+
+// msbuildProject holds the parts of a project file that matter for package resolution
+type msbuildProject struct {
+	Properties        map[string]string
+	PackageReferences []msbuildPackageReference
+	PackageVersions   []msbuildPackageReference
+}
+
+var msbuildPropertyPattern = regexp.MustCompile(`\$\(([^)]+)\)`)
+
+// ParseNugetProject parses a .csproj, .fsproj or .vbproj file. When a packages.lock.json sits next
+// to the project it is used instead, since it records the resolved versions. Otherwise versions come
+// from the PackageReference items, resolving $(Property) references and falling back to central
+// versions from the nearest Directory.Packages.props. References whose version cannot be resolved
+// are reported as warnings and floating or ranged versions are reported as unpinned. Lines are
+// those of the project file, so packages only in packages.lock.json have none.
+func ParseNugetProject(projectPath string) (*ParseResult, error) {
+	data, err := os.ReadFile(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	lockfilePath := filepath.Join(filepath.Dir(projectPath), "packages.lock.json")
+	if lockData, err := os.ReadFile(lockfilePath); err == nil {
+		packages, err := ParseNugetLockfile(lockData)
+		if err != nil {
+			return nil, err
+		}
+		return nugetLockedProject(data, packages)
+	}
+
+	var centralVersions map[string]string
+	if propsPath := findUpward(filepath.Dir(projectPath), "Directory.Packages.props"); propsPath != "" {
+		propsData, err := os.ReadFile(propsPath)
+		if err != nil {
+			return nil, err
+		}
+		if centralVersions, err = ParseDirectoryPackagesProps(propsData); err != nil {
+			return nil, err
+		}
+	}
+
+	return parseCsproj(projectPath, data, centralVersions)
+}
+
+// nugetLockedProject places the packages of a packages.lock.json on the PackageReference items of
+// the project that declare them
+func nugetLockedProject(data []byte, packages []PackageDescriptor) (*ParseResult, error) {
+	project, err := decodeMsbuildProject(data)
+	if err != nil {
+		return nil, fmt.Errorf("ParseNugetProject(): %w", err)
+	}
+
+	references := make(map[string]int)
+	for _, ref := range project.PackageReferences {
+		name := strings.ToLower(ref.attr("Include"))
+		if _, ok := references[name]; !ok && name != "" {
+			references[name] = ref.Line
+		}
+	}
+
+	result := &ParseResult{Packages: packages, Lines: make(map[PackageDescriptor]int)}
+	for _, pkg := range packages {
+		if line, ok := references[strings.ToLower(pkg.Name)]; ok {
+			result.Lines[pkg] = line
+		}
+	}
+	return result, nil
+}
+
+// ParseCsproj parses the contents of an SDK-style or legacy MSBuild project file. centralVersions
+// maps package IDs to versions from Directory.Packages.props and may be nil.
+func ParseCsproj(data []byte, centralVersions map[string]string) (*ParseResult, error) {
+	return parseCsproj("", data, centralVersions)
+}
+
+// parseCsproj parses a project file, recording projectPath as the file of unpinned references
+func parseCsproj(projectPath string, data []byte, centralVersions map[string]string) (*ParseResult, error) {
+	project, err := decodeMsbuildProject(data)
+	if err != nil {
+		return nil, fmt.Errorf("ParseCsproj(): %w", err)
+	}
+
+	result := &ParseResult{Lines: make(map[PackageDescriptor]int)}
+	for _, ref := range project.PackageReferences {
+		name := ref.attr("Include")
+		if name == "" {
+			// Update items modify references declared elsewhere
+			continue
+		}
+
+		version := ref.attr("VersionOverride")
+		if version == "" {
+			version = ref.attr("Version")
+		}
+		if version == "" {
+			version = strings.TrimSpace(ref.Version)
+		}
+		if version == "" {
+			version = lookupFold(centralVersions, name)
+		}
+		if version == "" {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: no version specified and no central package version found", name))
+			continue
+		}
+
+		resolved, err := resolveMsbuildProperties(version, project.Properties)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
+		pinned, ok := pinnedNugetVersion(resolved)
+		if !ok {
+			result.Unpinned = append(result.Unpinned, UnpinnedRequirement{Name: name, Specifier: resolved, File: projectPath, Line: ref.Line})
+			continue
+		}
+
+		pkg := PackageDescriptor{Name: name, Version: pinned, Type: Nuget}
+		if _, seen := result.Lines[pkg]; seen {
+			continue
+		}
+		result.Lines[pkg] = ref.Line
+		result.Packages = append(result.Packages, pkg)
+	}
+
+	return result, nil
+}
+
+// ParseDirectoryPackagesProps returns the central package versions declared by <PackageVersion> items
+func ParseDirectoryPackagesProps(data []byte) (map[string]string, error) {
+	project, err := decodeMsbuildProject(data)
+	if err != nil {
+		return nil, fmt.Errorf("ParseDirectoryPackagesProps(): %w", err)
+	}
+
+	versions := make(map[string]string)
+	for _, ref := range project.PackageVersions {
+		version := ref.attr("Version")
+		if version == "" {
+			version = strings.TrimSpace(ref.Version)
+		}
+		if resolved, err := resolveMsbuildProperties(version, project.Properties); err == nil {
+			version = resolved
+		}
+		if name := ref.attr("Include"); name != "" {
+			versions[name] = version
+		}
+	}
+	return versions, nil
+}
+
+type nugetLockfile struct {
+	Version      int                                    `json:"version"`
+	Dependencies map[string]map[string]nugetLockedEntry `json:"dependencies"`
+}
+
+type nugetLockedEntry struct {
+	Type     string `json:"type"`
+	Resolved string `json:"resolved"`
+}
+
+// ParseNugetLockfile parses the contents of a packages.lock.json. Packages are deduplicated across
+// target frameworks and project references are skipped.
+func ParseNugetLockfile(data []byte) ([]PackageDescriptor, error) {
+	var lockfile nugetLockfile
+	if err := json.Unmarshal(data, &lockfile); err != nil {
+		return nil, fmt.Errorf("ParseNugetLockfile(): failed to parse json: %w", err)
+	}
+
+	var result []PackageDescriptor
+	seen := make(map[PackageDescriptor]bool)
+	for _, framework := range sortedKeys(lockfile.Dependencies) {
+		entries := lockfile.Dependencies[framework]
+		for _, name := range sortedKeys(entries) {
+			entry := entries[name]
+			if strings.EqualFold(entry.Type, "Project") || entry.Resolved == "" {
+				continue
+			}
+			pkg := PackageDescriptor{Name: name, Version: entry.Resolved, Type: Nuget}
+			if seen[pkg] {
+				continue
+			}
+			seen[pkg] = true
+			result = append(result, pkg)
+		}
+	}
+	return result, nil
+}
+
+// decodeMsbuildProject walks an MSBuild XML document collecting properties and package items.
+// Properties from unconditional PropertyGroups take precedence over conditional ones since
+// conditions are not evaluated.
+func decodeMsbuildProject(data []byte) (*msbuildProject, error) {
+	project := &msbuildProject{Properties: map[string]string{}}
+	conditional := map[string]string{}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var stack []xml.StartElement
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse xml: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Local == "PackageReference" || t.Name.Local == "PackageVersion":
+				var ref msbuildPackageReference
+				ref.Line, _ = decoder.InputPos()
+				if err := decoder.DecodeElement(&ref, &t); err != nil {
+					return nil, fmt.Errorf("failed to parse xml: %w", err)
+				}
+				if t.Name.Local == "PackageReference" {
+					project.PackageReferences = append(project.PackageReferences, ref)
+				} else {
+					project.PackageVersions = append(project.PackageVersions, ref)
+				}
+				continue
+			case len(stack) > 0 && stack[len(stack)-1].Name.Local == "PropertyGroup":
+				var value string
+				if err := decoder.DecodeElement(&value, &t); err != nil {
+					return nil, fmt.Errorf("failed to parse xml: %w", err)
+				}
+				if msbuildCondition(stack[len(stack)-1]) != "" || msbuildCondition(t) != "" {
+					conditional[t.Name.Local] = strings.TrimSpace(value)
+				} else {
+					project.Properties[t.Name.Local] = strings.TrimSpace(value)
+				}
+				continue
+			}
+			stack = append(stack, t)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	for k, v := range conditional {
+		if _, ok := project.Properties[k]; !ok {
+			project.Properties[k] = v
+		}
+	}
+	return project, nil
+}
+
+func msbuildCondition(elem xml.StartElement) string {
+	for _, attr := range elem.Attr {
+		if attr.Name.Local == "Condition" {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// resolveMsbuildProperties substitutes $(Property) references from properties
+func resolveMsbuildProperties(value string, properties map[string]string) (string, error) {
+	for i := 0; i < 10 && strings.Contains(value, "$("); i++ {
+		var missing []string
+		value = msbuildPropertyPattern.ReplaceAllStringFunc(value, func(reference string) string {
+			key := reference[2 : len(reference)-1]
+			if resolved, ok := properties[key]; ok && resolved != "" {
+				return resolved
+			}
+			missing = append(missing, key)
+			return reference
+		})
+		if len(missing) > 0 {
+			return "", fmt.Errorf("unresolved property $(%s)", strings.Join(missing, "), $("))
+		}
+	}
+	if strings.Contains(value, "$(") {
+		return "", fmt.Errorf("property expansion did not terminate for %s", value)
+	}
+	return value, nil
+}
+
+// pinnedNugetVersion returns the version a NuGet version specifier resolves to exactly. A bare
+// version is a minimum in NuGet semantics but is what restore selects, so it is treated as pinned,
+// as is the exact range "[1.2.3]". Floating versions and other ranges are not pinned.
+func pinnedNugetVersion(specifier string) (string, bool) {
+	specifier = strings.TrimSpace(specifier)
+	if strings.Contains(specifier, "*") {
+		return "", false
+	}
+	if strings.HasPrefix(specifier, "[") && strings.HasSuffix(specifier, "]") && !strings.Contains(specifier, ",") {
+		return strings.TrimSpace(specifier[1 : len(specifier)-1]), true
+	}
+	if strings.ContainsAny(specifier, "[](),") {
+		return "", false
+	}
+	return specifier, specifier != ""
+}
+
+// findUpward searches dir and its parents for a file named name, returning its path or ""
+func findUpward(dir string, name string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		candidate := filepath.Join(dir, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// lookupFold returns the value for key in m, matching keys case-insensitively like NuGet package IDs
+func lookupFold(m map[string]string, key string) string {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,315 @@
+package phylum
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+type poetryLockfile struct {
+	Package []struct {
+		Name    string `toml:"name"`
+		Version string `toml:"version"`
+		Source  *struct {
+			Type string `toml:"type"`
+			Url  string `toml:"url"`
+		} `toml:"source"`
+		Dependencies map[string]interface{} `toml:"dependencies"`
+	} `toml:"package"`
+}
+
+// ParsePoetryLock parses the contents of a poetry.lock. Packages installed from a local
+// directory, a file or an archive URL are skipped since they have no registry version.
+func ParsePoetryLock(data []byte) ([]PackageDescriptor, error) {
+	var lockfile poetryLockfile
+	if err := toml.Unmarshal(data, &lockfile); err != nil {
+		return nil, fmt.Errorf("ParsePoetryLock(): failed to parse toml: %w", err)
+	}
+
+	var result []PackageDescriptor
+	for _, pkg := range lockfile.Package {
+		if pkg.Source != nil {
+			switch pkg.Source.Type {
+			case "directory", "file", "url":
+				continue
+			}
+		}
+		result = append(result, PackageDescriptor{Name: pkg.Name, Version: pkg.Version, Type: Pypi})
+	}
+	return result, nil
+}
+
+// PoetryDependencyGraph builds a dependency graph from the contents of a poetry.lock. Dependency
+// names are matched after PEP 503 normalization. poetry.lock doesn't record which packages the
+// project requires itself, so packages nothing else depends on are treated as direct.
+func PoetryDependencyGraph(data []byte) (*DependencyGraph, error) {
+	var lockfile poetryLockfile
+	if err := toml.Unmarshal(data, &lockfile); err != nil {
+		return nil, fmt.Errorf("PoetryDependencyGraph(): failed to parse toml: %w", err)
+	}
+
+	g := NewDependencyGraph()
+	byName := make(map[string]PackageDescriptor)
+	for _, pkg := range lockfile.Package {
+		if pkg.Source != nil && (pkg.Source.Type == "directory" || pkg.Source.Type == "file" || pkg.Source.Type == "url") {
+			continue
+		}
+		descriptor := PackageDescriptor{Name: pkg.Name, Version: pkg.Version, Type: Pypi}
+		g.AddPackage(descriptor, false)
+		byName[normalizePythonName(pkg.Name)] = descriptor
+	}
+
+	for _, pkg := range lockfile.Package {
+		parent, ok := byName[normalizePythonName(pkg.Name)]
+		if !ok || parent.Version != pkg.Version {
+			continue
+		}
+		for _, name := range sortedKeys(pkg.Dependencies) {
+			if child, ok := byName[normalizePythonName(name)]; ok {
+				g.AddEdge(parent, child)
+			}
+		}
+	}
+	g.markRoots()
+	return g, nil
+}
+
+var pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePythonName normalizes a distribution name as described in PEP 503
+func normalizePythonName(name string) string {
+	return pythonNameSeparators.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+type pipfileLockfile struct {
+	Default map[string]pipfileLockEntry `json:"default"`
+	Develop map[string]pipfileLockEntry `json:"develop"`
+}
+
+type pipfileLockEntry struct {
+	Version string `json:"version"`
+}
+
+// ParsePipfileLock parses the contents of a Pipfile.lock, including both the default and
+// develop sections. Git, path and file entries carry no version and are skipped.
+func ParsePipfileLock(data []byte) ([]PackageDescriptor, error) {
+	var lockfile pipfileLockfile
+	if err := json.Unmarshal(data, &lockfile); err != nil {
+		return nil, fmt.Errorf("ParsePipfileLock(): failed to parse json: %w", err)
+	}
+
+	var result []PackageDescriptor
+	for _, section := range []map[string]pipfileLockEntry{lockfile.Default, lockfile.Develop} {
+		for _, name := range sortedKeys(section) {
+			version := strings.TrimPrefix(section[name].Version, "==")
+			if version == "" {
+				continue
+			}
+			result = append(result, PackageDescriptor{Name: name, Version: version, Type: Pypi})
+		}
+	}
+	return result, nil
+}
+
+// ParsePipfile parses the contents of a Pipfile. Only "==" pinned packages become
+// PackageDescriptors; everything else is reported as unpinned or as a warning.
+func ParsePipfile(data []byte) (*ParseResult, error) {
+	var pipfile map[string]interface{}
+	if err := toml.Unmarshal(data, &pipfile); err != nil {
+		return nil, fmt.Errorf("ParsePipfile(): failed to parse toml: %w", err)
+	}
+
+	result := &ParseResult{}
+	for _, sectionName := range []string{"packages", "dev-packages"} {
+		section, _ := pipfile[sectionName].(map[string]interface{})
+		for _, name := range sortedKeys(section) {
+			var specifier string
+			switch value := section[name].(type) {
+			case string:
+				specifier = value
+			case map[string]interface{}:
+				version, ok := value["version"].(string)
+				if !ok {
+					result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s is not installed from an index", sectionName, name))
+					continue
+				}
+				specifier = version
+			}
+
+			if version, ok := pinnedPythonVersion(specifier); ok {
+				result.Packages = append(result.Packages, PackageDescriptor{Name: name, Version: version, Type: Pypi})
+			} else {
+				result.Unpinned = append(result.Unpinned, UnpinnedRequirement{Name: name, Specifier: specifier, File: "Pipfile"})
+			}
+		}
+	}
+	return result, nil
+}
+
+var (
+	requirementPattern        = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*(.*)$`)
+	requirementCommentPattern = regexp.MustCompile(`(^|\s)#.*$`)
+)
+
+// ParseRequirementsFile parses a pip requirements file, following -r includes relative to the
+// including file. Requirements pinned with "==" become PackageDescriptors, requirements with
+// any other specifier are reported as unpinned, and URLs, local paths and editable installs
+// are reported as warnings. Lines are recorded for packages pinned in requirementsPath itself.
+func ParseRequirementsFile(requirementsPath string) (*ParseResult, error) {
+	result := &ParseResult{Lines: make(map[PackageDescriptor]int)}
+	if err := parseRequirementsFile(requirementsPath, requirementsPath, result, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func parseRequirementsFile(requirementsPath string, root string, result *ParseResult, visited map[string]bool) error {
+	absPath, err := filepath.Abs(requirementsPath)
+	if err != nil {
+		return err
+	}
+	if visited[absPath] {
+		return nil
+	}
+	visited[absPath] = true
+
+	file, err := os.Open(requirementsPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var logical strings.Builder
+	startLine := 0
+	lineNum := 0
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if logical.Len() == 0 {
+			startLine = lineNum
+		}
+
+		// Joined lines are handled before comments so a continuation can't hide a "#"
+		if strings.HasSuffix(line, "\\") {
+			logical.WriteString(strings.TrimSuffix(line, "\\"))
+			logical.WriteString(" ")
+			continue
+		}
+		logical.WriteString(line)
+
+		text := logical.String()
+		logical.Reset()
+		if err := parseRequirementLine(text, requirementsPath, root, startLine, result, visited); err != nil {
+			return err
+		}
+	}
+	if logical.Len() > 0 {
+		if err := parseRequirementLine(logical.String(), requirementsPath, root, startLine, result, visited); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func parseRequirementLine(text string, requirementsPath string, root string, lineNum int, result *ParseResult, visited map[string]bool) error {
+	// Comments start at a "#" at the beginning of the line or after whitespace
+	text = strings.TrimSpace(requirementCommentPattern.ReplaceAllString(text, ""))
+	if text == "" {
+		return nil
+	}
+	location := fmt.Sprintf("%s:%d", requirementsPath, lineNum)
+
+	if strings.HasPrefix(text, "-") {
+		fields := strings.Fields(strings.Replace(text, "=", " ", 1))
+		switch fields[0] {
+		case "-r", "--requirement":
+			if len(fields) < 2 {
+				return fmt.Errorf("ParseRequirementsFile(): %s: missing path for %s", location, fields[0])
+			}
+			include := fields[1]
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(filepath.Dir(requirementsPath), include)
+			}
+			if _, err := os.Stat(include); err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s: included file %s could not be read", location, fields[1]))
+				return nil
+			}
+			return parseRequirementsFile(include, root, result, visited)
+		case "-e", "--editable":
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: editable requirement skipped: %s", location, text))
+		case "-c", "--constraint":
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: constraints file ignored: %s", location, text))
+		}
+		// Index and install options don't declare packages
+		return nil
+	}
+
+	// Per-requirement options such as --hash and --install-option follow the requirement
+	if idx := strings.Index(text, " --"); idx >= 0 {
+		text = strings.TrimSpace(text[:idx])
+	}
+
+	if strings.Contains(text, "://") || strings.HasPrefix(text, ".") || strings.HasPrefix(text, "/") {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s: URL or path requirement skipped: %s", location, text))
+		return nil
+	}
+
+	// Environment markers don't affect the pinned version
+	if idx := strings.Index(text, ";"); idx >= 0 {
+		text = strings.TrimSpace(text[:idx])
+	}
+
+	match := requirementPattern.FindStringSubmatch(text)
+	if match == nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s: unrecognized requirement: %s", location, text))
+		return nil
+	}
+	name, specifier := match[1], strings.TrimSpace(match[3])
+	if strings.HasPrefix(specifier, "@") {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s: direct reference skipped: %s", location, text))
+		return nil
+	}
+
+	if version, ok := pinnedPythonVersion(specifier); ok {
+		pkg := PackageDescriptor{Name: name, Version: version, Type: Pypi}
+		result.Packages = append(result.Packages, pkg)
+		// Lines refer to the file being parsed, not to the files it includes
+		if _, ok := result.Lines[pkg]; !ok && requirementsPath == root {
+			result.Lines[pkg] = lineNum
+		}
+	} else {
+		result.Unpinned = append(result.Unpinned, UnpinnedRequirement{Name: name, Specifier: specifier, File: requirementsPath, Line: lineNum})
+	}
+	return nil
+}
+
+// pinnedPythonVersion returns the version of a specifier that pins exactly one version with == or ===
+func pinnedPythonVersion(specifier string) (string, bool) {
+	specifier = strings.TrimSpace(specifier)
+	if strings.Contains(specifier, ",") {
+		return "", false
+	}
+
+	var version string
+	switch {
+	case strings.HasPrefix(specifier, "==="):
+		version = strings.TrimSpace(strings.TrimPrefix(specifier, "==="))
+	case strings.HasPrefix(specifier, "=="):
+		version = strings.TrimSpace(strings.TrimPrefix(specifier, "=="))
+	default:
+		return "", false
+	}
+
+	if version == "" || strings.Contains(version, "*") {
+		return "", false
+	}
+	return version, true
+}
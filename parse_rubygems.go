@@ -0,0 +1,196 @@
+package phylum
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// GemSpec is a resolved gem from a specs: block of a Gemfile.lock
+type GemSpec struct {
+	Name         string
+	Version      string
+	Platform     string   // Platform suffix such as x86_64-linux, empty for pure Ruby gems
+	Source       string   // Section the gem was declared in: GEM, GIT or PATH
+	Remote       string   // Remote of the declaring section
+	Dependencies []string // Names of the gems this gem depends on
+	Direct       bool     // Listed in the DEPENDENCIES section
+	Line         int
+}
+
+// GemfileLock is a parsed Gemfile.lock
+type GemfileLock struct {
+	Specs        []GemSpec
+	Dependencies []string // Gem names from the DEPENDENCIES section
+}
+
+// ParseGemfileLock parses the contents of a Gemfile.lock, walking the GEM, GIT and PATH sections
+// and the DEPENDENCIES section that marks direct dependencies.
+func ParseGemfileLock(data []byte) (*GemfileLock, error) {
+	lock := &GemfileLock{}
+	var section, remote string
+	inSpecs := false
+	var current *GemSpec
+	lineNum := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		text := strings.TrimSpace(line)
+
+		if indent == 0 {
+			section = text
+			remote = ""
+			inSpecs = false
+			current = nil
+			continue
+		}
+
+		switch section {
+		case "GEM", "GIT", "PATH", "PLUGIN SOURCE":
+			switch {
+			case indent == 2 && strings.HasPrefix(text, "remote:"):
+				remote = strings.TrimSpace(strings.TrimPrefix(text, "remote:"))
+			case indent == 2:
+				inSpecs = text == "specs:"
+			case indent == 4 && inSpecs:
+				name, version, ok := splitGemEntry(text)
+				if !ok {
+					return nil, &LockfileSyntaxError{Line: lineNum, Column: 5, Msg: fmt.Sprintf("expected \"name (version)\", got %q", text)}
+				}
+				version, platform := splitGemPlatform(version)
+				lock.Specs = append(lock.Specs, GemSpec{
+					Name:     name,
+					Version:  version,
+					Platform: platform,
+					Source:   section,
+					Remote:   remote,
+					Line:     lineNum,
+				})
+				current = &lock.Specs[len(lock.Specs)-1]
+			case indent == 6 && inSpecs && current != nil:
+				name, _, _ := splitGemEntry(text)
+				current.Dependencies = append(current.Dependencies, name)
+			}
+		case "DEPENDENCIES":
+			name, _, _ := splitGemEntry(text)
+			lock.Dependencies = append(lock.Dependencies, strings.TrimSuffix(name, "!"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ParseGemfileLock(): failed to read lockfile: %w", err)
+	}
+
+	direct := make(map[string]bool, len(lock.Dependencies))
+	for _, name := range lock.Dependencies {
+		direct[name] = true
+	}
+	for i := range lock.Specs {
+		lock.Specs[i].Direct = direct[lock.Specs[i].Name]
+	}
+
+	return lock, nil
+}
+
+// Packages returns every gem from the GEM and GIT sections. Gems from PATH sections are local
+// sources and are skipped. Platform variants of the same version are reported once.
+func (g *GemfileLock) Packages() []PackageDescriptor {
+	return g.packages(func(GemSpec) bool { return true })
+}
+
+// DirectPackages returns the gems listed in the DEPENDENCIES section
+func (g *GemfileLock) DirectPackages() []PackageDescriptor {
+	return g.packages(func(spec GemSpec) bool { return spec.Direct })
+}
+
+// TransitivePackages returns the gems that are only present as dependencies of other gems
+func (g *GemfileLock) TransitivePackages() []PackageDescriptor {
+	return g.packages(func(spec GemSpec) bool { return !spec.Direct })
+}
+
+// DependencyGraph returns the gems and their dependencies as a graph, with the gems from the
+// DEPENDENCIES section marked direct. Gems from PATH sections are skipped.
+func (g *GemfileLock) DependencyGraph() *DependencyGraph {
+	graph := NewDependencyGraph()
+	byName := make(map[string]PackageDescriptor)
+	for _, spec := range g.Specs {
+		if spec.Source == "PATH" {
+			continue
+		}
+		pkg := PackageDescriptor{Name: spec.Name, Version: spec.Version, Type: Rubygems}
+		graph.AddPackage(pkg, spec.Direct)
+		if _, ok := byName[spec.Name]; !ok {
+			byName[spec.Name] = pkg
+		}
+	}
+
+	for _, spec := range g.Specs {
+		if spec.Source == "PATH" {
+			continue
+		}
+		parent := PackageDescriptor{Name: spec.Name, Version: spec.Version, Type: Rubygems}
+		for _, name := range spec.Dependencies {
+			if child, ok := byName[name]; ok {
+				graph.AddEdge(parent, child)
+			}
+		}
+	}
+	return graph
+}
+
+func (g *GemfileLock) packages(include func(GemSpec) bool) []PackageDescriptor {
+	var result []PackageDescriptor
+	seen := make(map[PackageDescriptor]bool)
+
+	for _, spec := range g.Specs {
+		if spec.Source == "PATH" || !include(spec) {
+			continue
+		}
+		pkg := PackageDescriptor{Name: spec.Name, Version: spec.Version, Type: Rubygems}
+		if seen[pkg] {
+			continue
+		}
+		seen[pkg] = true
+		result = append(result, pkg)
+	}
+	return result
+}
+
+// lines returns the line of the first spec declaring each package returned by Packages
+func (g *GemfileLock) lines() map[PackageDescriptor]int {
+	lines := make(map[PackageDescriptor]int)
+	for _, spec := range g.Specs {
+		pkg := PackageDescriptor{Name: spec.Name, Version: spec.Version, Type: Rubygems}
+		if _, ok := lines[pkg]; !ok && spec.Source != "PATH" {
+			lines[pkg] = spec.Line
+		}
+	}
+	return lines
+}
+
+// splitGemEntry splits "name (version)" into its name and parenthesised part
+func splitGemEntry(text string) (string, string, bool) {
+	open := strings.Index(text, " (")
+	if open < 0 {
+		return text, "", false
+	}
+	if !strings.HasSuffix(text, ")") {
+		return text[:open], "", false
+	}
+	return text[:open], text[open+2 : len(text)-1], true
+}
+
+// splitGemPlatform separates a platform suffix such as "-x86_64-linux" from a gem version.
+// RubyGems versions use "." for prereleases, so the first "-" always starts the platform.
+func splitGemPlatform(version string) (string, string) {
+	if idx := strings.Index(version, "-"); idx > 0 {
+		return version[:idx], version[idx+1:]
+	}
+	return version, ""
+}
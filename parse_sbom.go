@@ -0,0 +1,182 @@
+package phylum
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SBOM formats recognised by ParseSBOM
+const (
+	SBOMFormatCycloneDX = "cyclonedx"
+	SBOMFormatSPDX      = "spdx"
+)
+
+// SBOMImport is the result of reading an existing SBOM
+type SBOMImport struct {
+	Format      string
+	Packages    []PackageDescriptor
+	Unsupported []SBOMComponent // Components that cannot be analysed, with the reason why
+}
+
+// SBOMComponent is a component of an SBOM that could not be converted to a PackageDescriptor
+type SBOMComponent struct {
+	Name    string
+	Version string
+	Purl    string
+	Reason  string
+}
+
+// ByEcosystem groups the packages by package type, for analysing each ecosystem as its own project
+func (s *SBOMImport) ByEcosystem() map[PackageType][]PackageDescriptor {
+	grouped := make(map[PackageType][]PackageDescriptor)
+	for _, pkg := range s.Packages {
+		grouped[pkg.Type] = append(grouped[pkg.Type], pkg)
+	}
+	return grouped
+}
+
+// LoadSBOM reads a CycloneDX or SPDX JSON file
+func LoadSBOM(sbomPath string) (*SBOMImport, error) {
+	data, err := os.ReadFile(sbomPath)
+	if err != nil {
+		return nil, fmt.Errorf("LoadSBOM(): %w", err)
+	}
+	result, err := ParseSBOM(data)
+	if err != nil {
+		return nil, fmt.Errorf("LoadSBOM(): %v: %w", sbomPath, err)
+	}
+	return result, nil
+}
+
+// ParseSBOM converts the components of a CycloneDX or SPDX JSON document to packages using their
+// package URLs. Components without a purl, without a version or with a purl type Phylum does not
+// support are reported in Unsupported.
+func ParseSBOM(data []byte) (*SBOMImport, error) {
+	var probe struct {
+		BOMFormat   string `json:"bomFormat"`
+		SPDXVersion string `json:"spdxVersion"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("ParseSBOM(): %w", err)
+	}
+
+	switch {
+	case strings.EqualFold(probe.BOMFormat, "CycloneDX"):
+		return parseCycloneDXSBOM(data)
+	case strings.HasPrefix(probe.SPDXVersion, "SPDX-"):
+		return parseSPDXSBOM(data)
+	}
+	return nil, fmt.Errorf("ParseSBOM(): not a CycloneDX or SPDX JSON document")
+}
+
+type cyclonedxImportComponent struct {
+	Name       string                     `json:"name"`
+	Group      string                     `json:"group"`
+	Version    string                     `json:"version"`
+	Purl       string                     `json:"purl"`
+	Components []cyclonedxImportComponent `json:"components"`
+}
+
+func parseCycloneDXSBOM(data []byte) (*SBOMImport, error) {
+	var bom struct {
+		Components []cyclonedxImportComponent `json:"components"`
+	}
+	if err := json.Unmarshal(data, &bom); err != nil {
+		return nil, fmt.Errorf("ParseSBOM(): %w", err)
+	}
+
+	result := &SBOMImport{Format: SBOMFormatCycloneDX}
+	var walk func(components []cyclonedxImportComponent)
+	walk = func(components []cyclonedxImportComponent) {
+		for _, c := range components {
+			name := c.Name
+			if c.Group != "" {
+				name = c.Group + "/" + c.Name
+			}
+			result.add(name, c.Version, c.Purl)
+			walk(c.Components)
+		}
+	}
+	walk(bom.Components)
+	result.Packages = DedupePackages(result.Packages)
+	return result, nil
+}
+
+func parseSPDXSBOM(data []byte) (*SBOMImport, error) {
+	var doc struct {
+		DocumentDescribes []string `json:"documentDescribes"`
+		Packages          []struct {
+			SPDXID       string `json:"SPDXID"`
+			Name         string `json:"name"`
+			VersionInfo  string `json:"versionInfo"`
+			ExternalRefs []struct {
+				ReferenceType    string `json:"referenceType"`
+				ReferenceLocator string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+		} `json:"packages"`
+		Relationships []SPDXRelationship `json:"relationships"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("ParseSBOM(): %w", err)
+	}
+
+	// The packages a document describes are the subject of the SBOM, not its dependencies
+	described := make(map[string]bool)
+	for _, id := range doc.DocumentDescribes {
+		described[id] = true
+	}
+	for _, r := range doc.Relationships {
+		if r.SPDXElementID == spdxDocumentID && r.RelationshipType == "DESCRIBES" {
+			described[r.RelatedSPDXElement] = true
+		}
+	}
+
+	result := &SBOMImport{Format: SBOMFormatSPDX}
+	for _, pkg := range doc.Packages {
+		if described[pkg.SPDXID] {
+			continue
+		}
+		purl := ""
+		for _, ref := range pkg.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				purl = ref.ReferenceLocator
+				break
+			}
+		}
+		result.add(pkg.Name, pkg.VersionInfo, purl)
+	}
+	result.Packages = DedupePackages(result.Packages)
+	return result, nil
+}
+
+func (s *SBOMImport) add(name string, version string, purl string) {
+	component := SBOMComponent{Name: name, Version: version, Purl: purl}
+	if purl == "" {
+		component.Reason = "no package URL"
+		s.Unsupported = append(s.Unsupported, component)
+		return
+	}
+
+	pkg, err := ParsePackageURL(purl)
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedPackageURL) {
+			component.Reason = "unsupported package URL type"
+		} else {
+			component.Reason = "invalid package URL"
+		}
+		s.Unsupported = append(s.Unsupported, component)
+		return
+	}
+	if pkg.Version == "" {
+		pkg.Version = version
+	}
+	if pkg.Version == "" {
+		component.Reason = "no version"
+		s.Unsupported = append(s.Unsupported, component)
+		return
+	}
+	s.Packages = append(s.Packages, pkg)
+}
@@ -0,0 +1,92 @@
+package phylum
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestParseSBOM(t *testing.T) {
+	wantPackages := []PackageDescriptor{
+		{Name: "@types/node", Version: "18.0.0", Type: Npm},
+		{Name: "express", Version: "4.18.2", Type: Npm},
+		{Name: "qs", Version: "6.11.0", Type: Npm},
+	}
+
+	var cyclonedx, spdx bytes.Buffer
+	if err := NewCycloneDXFromJob(testSBOMJob(), nil).WriteJSON(&cyclonedx); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewSPDXFromJob(testSBOMJob(), nil).WriteJSON(&spdx); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		data            string
+		wantFormat      string
+		wantPackages    []PackageDescriptor
+		wantUnsupported []string
+		wantErr         bool
+	}{
+		{"cyclonedx export", cyclonedx.String(), SBOMFormatCycloneDX, wantPackages, nil, false},
+		{"spdx export", spdx.String(), SBOMFormatSPDX, wantPackages, nil, false},
+		{
+			"cyclonedx nested and unsupported",
+			`{"bomFormat": "CycloneDX", "specVersion": "1.4", "components": [
+				{"type": "library", "name": "commons-lang3", "group": "org.apache.commons", "version": "3.12.0",
+				 "purl": "pkg:maven/org.apache.commons/commons-lang3@3.12.0",
+				 "components": [{"type": "library", "name": "serde", "version": "1.0.0", "purl": "pkg:cargo/serde@1.0.0"}]},
+				{"type": "library", "name": "requests", "purl": "pkg:pypi/requests"},
+				{"type": "library", "name": "Requests", "version": "2.28.0", "purl": "pkg:pypi/requests@2.28.0"},
+				{"type": "file", "name": "app.jar"}
+			]}`,
+			SBOMFormatCycloneDX,
+			[]PackageDescriptor{
+				{Name: "org.apache.commons:commons-lang3", Version: "3.12.0", Type: Maven},
+				{Name: "requests", Version: "2.28.0", Type: Pypi},
+			},
+			[]string{"serde: unsupported package URL type", "requests: no version", "app.jar: no package URL"},
+			false,
+		},
+		{
+			"spdx from another tool",
+			`{"spdxVersion": "SPDX-2.2", "documentDescribes": ["SPDXRef-app"], "packages": [
+				{"SPDXID": "SPDXRef-app", "name": "app"},
+				{"SPDXID": "SPDXRef-1", "name": "rails", "versionInfo": "7.0.4", "externalRefs": [
+					{"referenceCategory": "SECURITY", "referenceType": "cpe23Type", "referenceLocator": "cpe:2.3:a:rails:rails:7.0.4"},
+					{"referenceCategory": "PACKAGE_MANAGER", "referenceType": "purl", "referenceLocator": "pkg:gem/rails"}]}
+			]}`,
+			SBOMFormatSPDX,
+			[]PackageDescriptor{{Name: "rails", Version: "7.0.4", Type: Rubygems}},
+			nil,
+			false,
+		},
+		{"unknown document", `{"name": "package.json"}`, "", nil, nil, true},
+		{"not json", `<bom/>`, "", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSBOM([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSBOM() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Format != tt.wantFormat {
+				t.Errorf("ParseSBOM() format got = %v, want %v", got.Format, tt.wantFormat)
+			}
+			if !reflect.DeepEqual(got.Packages, tt.wantPackages) {
+				t.Errorf("ParseSBOM() packages got = %+v, want %+v", got.Packages, tt.wantPackages)
+			}
+			var unsupported []string
+			for _, c := range got.Unsupported {
+				unsupported = append(unsupported, c.Name+": "+c.Reason)
+			}
+			if !reflect.DeepEqual(unsupported, tt.wantUnsupported) {
+				t.Errorf("ParseSBOM() unsupported got = %v, want %v", unsupported, tt.wantUnsupported)
+			}
+		})
+	}
+}
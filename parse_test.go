@@ -0,0 +1,556 @@
+package phylum
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+)
+
+func TestParseLockfileOffline(t *testing.T) {
+	type args struct {
+		lockfilePath string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantLen  int
+		wantType PackageType
+		wantErr  bool
+		// Requirements reported in an UnpinnedRequirementsError alongside the pinned packages
+		wantUnpinned int
+	}{
+		{"package-lock", args{"test_lockfiles/package-lock.json"}, 52, Npm, false, 0},
+		{"package-lock-v6", args{"test_lockfiles/package-lock-v6.json"}, 17, Npm, false, 0},
+		{"yarn berry", args{"test_lockfiles/yarn.lock"}, 53, Npm, false, 0},
+		{"yarn berry patch", args{"test_lockfiles/yarn-berry-patch.lock"}, 4, Npm, false, 0},
+		{"yarn v1", args{"test_lockfiles/yarn-v1.lock"}, 17, Npm, false, 0},
+		{"yarn v1 simple", args{"test_lockfiles/yarn-v1.simple.lock"}, 1, Npm, false, 0},
+		{"yarn v1 trailing newlines", args{"test_lockfiles/yarn-v1.trailing_newlines.lock"}, 17, Npm, false, 0},
+		{"yarn v1 bad", args{"test_lockfiles/yarn-v1.lock.bad"}, 0, "", true, 0},
+		{"requirements.txt", args{"test_lockfiles/requirements.txt"}, 131, Pypi, false, 0},
+		{"complex requirements", args{"test_lockfiles/complex-requirements.txt"}, 5, Pypi, false, 25},
+		{"poetry.lock", args{"test_lockfiles/poetry.lock"}, 45, Pypi, false, 0},
+		{"pipfile.lock", args{"test_lockfiles/Pipfile.lock"}, 27, Pypi, false, 0},
+		{"pipfile", args{"test_lockfiles/Pipfile"}, 4, Pypi, false, 30},
+		{"effective-pom", args{"test_lockfiles/effective-pom.xml"}, 5, Maven, false, 0},
+		{"workspace-effective-pom", args{"test_lockfiles/workspace-effective-pom.xml"}, 42, Maven, false, 0},
+		{"gradle.lockfile", args{"test_lockfiles/gradle.lockfile"}, 6, Maven, false, 0},
+		{"Calculator csproj", args{"test_lockfiles/Calculator.csproj"}, 2, Nuget, false, 0},
+		{"sample csproj", args{"test_lockfiles/sample.csproj"}, 5, Nuget, false, 0},
+		{"Gemfile.lock", args{"test_lockfiles/Gemfile.lock"}, 214, Rubygems, false, 0},
+		{"missing", args{"test_lockfiles/does-not-exist.json"}, 0, "", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLockfileOffline(tt.args.lockfilePath)
+			var unpinnedErr *UnpinnedRequirementsError
+			if errors.As(err, &unpinnedErr) {
+				if len(unpinnedErr.Unpinned) != tt.wantUnpinned {
+					t.Errorf("ParseLockfileOffline(): len of unpinned requirements got = %v, want %v", len(unpinnedErr.Unpinned), tt.wantUnpinned)
+				}
+				err = nil
+			} else if tt.wantUnpinned > 0 {
+				t.Errorf("ParseLockfileOffline() error = %v, want UnpinnedRequirementsError", err)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseLockfileOffline() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("ParseLockfileOffline(): len of parsed packages: got = %v, want %v", len(got), tt.wantLen)
+			}
+			for _, pkg := range got {
+				if pkg.Type != tt.wantType {
+					t.Errorf("ParseLockfileOffline(): package %v type got = %v, want %v", pkg.Name, pkg.Type, tt.wantType)
+				}
+			}
+		})
+	}
+}
+
+func TestParseNpmLockfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []PackageDescriptor
+		wantErr bool
+	}{
+		{
+			"v1 nested, bundled, file and alias",
+			`{"lockfileVersion": 1, "dependencies": {
+				"a": {"version": "1.0.0", "dependencies": {"b": {"version": "2.0.0"}}},
+				"c": {"version": "1.0.0", "bundled": true},
+				"d": {"version": "file:../d"},
+				"e": {"version": "npm:@scope/real@3.1.0"}
+			}}`,
+			[]PackageDescriptor{
+				{Name: "a", Version: "1.0.0", Type: Npm},
+				{Name: "b", Version: "2.0.0", Type: Npm},
+				{Name: "@scope/real", Version: "3.1.0", Type: Npm},
+			},
+			false,
+		},
+		{
+			"v3 packages, link, bundle and scoped",
+			`{"lockfileVersion": 3, "packages": {
+				"": {"name": "root", "version": "1.0.0"},
+				"packages/ws": {"version": "0.1.0"},
+				"node_modules/ws": {"resolved": "packages/ws", "link": true},
+				"node_modules/@scope/pkg": {"version": "1.2.3"},
+				"node_modules/@scope/pkg/node_modules/inner": {"version": "0.0.1", "inBundle": true},
+				"node_modules/x/node_modules/y": {"version": "4.0.0"}
+			}}`,
+			[]PackageDescriptor{
+				{Name: "@scope/pkg", Version: "1.2.3", Type: Npm},
+				{Name: "y", Version: "4.0.0", Type: Npm},
+			},
+			false,
+		},
+		{"invalid", `{"packages": [}`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseNpmLockfile([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseNpmLockfile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseNpmLockfile() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseYarnLockfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []PackageDescriptor
+		wantErr bool
+	}{
+		{
+			"v1 multi-specifier, alias and workspace",
+			"# yarn lockfile v1\n\n" +
+				"\"@scope/a@^1.0.0\", \"@scope/a@^1.1.0\":\n  version \"1.2.0\"\n  dependencies:\n    b \"^2.0.0\"\n\n" +
+				"b@^2.0.0:\n  version \"2.0.1\"\n\n" +
+				"\"c@npm:real-c@^3\":\n  version \"3.0.0\"\n\n" +
+				"\"ws@workspace:packages/ws\":\n  version \"0.0.0\"\n",
+			[]PackageDescriptor{
+				{Name: "@scope/a", Version: "1.2.0", Type: Npm},
+				{Name: "b", Version: "2.0.1", Type: Npm},
+				{Name: "real-c", Version: "3.0.0", Type: Npm},
+			},
+			false,
+		},
+		{
+			"berry patch and workspace",
+			"__metadata:\n  version: 6\n\n" +
+				"\"a@npm:1.0.0, a@npm:^1.0.0\":\n  version: 1.0.0\n  resolution: \"a@npm:1.0.0\"\n  linkType: hard\n\n" +
+				"\"b@patch:b@npm:2.0.0#./b.patch::locator=root%40workspace%3A.\":\n  version: 2.0.0\n  resolution: \"b@patch:b@npm%3A2.0.0#./b.patch::version=2.0.0\"\n  linkType: hard\n\n" +
+				"\"root@workspace:.\":\n  version: 0.0.0-use.local\n  resolution: \"root@workspace:.\"\n  linkType: soft\n",
+			[]PackageDescriptor{
+				{Name: "a", Version: "1.0.0", Type: Npm},
+				{Name: "b", Version: "2.0.0", Type: Npm},
+			},
+			false,
+		},
+		{"v1 unindented field", "a@^1.0.0:\nversion \"1.0.0\"\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseYarnLockfile([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseYarnLockfile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseYarnLockfile() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseYarnLockfile_SyntaxErrorPosition(t *testing.T) {
+	data, err := os.ReadFile("test_lockfiles/yarn-v1.lock.bad")
+	if err != nil {
+		t.Fatalf("failed to read lockfile: %v", err)
+	}
+
+	_, err = ParseYarnLockfile(data)
+	var syntaxErr *LockfileSyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("ParseYarnLockfile() error = %v, want *LockfileSyntaxError", err)
+	}
+	if syntaxErr.Line != 10 || syntaxErr.Column != 11 {
+		t.Errorf("ParseYarnLockfile() error position got = %v:%v, want 10:11", syntaxErr.Line, syntaxErr.Column)
+	}
+}
+
+func TestParseRequirementsFile(t *testing.T) {
+	got, err := ParseRequirementsFile("test_lockfiles/complex-requirements.txt")
+	if err != nil {
+		t.Fatalf("ParseRequirementsFile() error = %v", err)
+	}
+
+	wantPackages := []PackageDescriptor{
+		{Name: "docopt", Version: "0.6.1", Type: Pypi},
+		{Name: "SomeProject2", Version: "1.3", Type: Pypi},
+		{Name: "SomeProject6", Version: "5.4", Type: Pypi},
+		{Name: "SomeProject11", Version: "1.2", Type: Pypi},
+		{Name: "FooProject5", Version: "1.5", Type: Pypi},
+	}
+	if !reflect.DeepEqual(got.Packages, wantPackages) {
+		t.Errorf("ParseRequirementsFile() packages got = %v, want %v", got.Packages, wantPackages)
+	}
+
+	unpinned := make(map[string]UnpinnedRequirement)
+	for _, req := range got.Unpinned {
+		unpinned[req.Name] = req
+	}
+	for _, name := range []string{"nose", "keyring", "SomeProject4", "SomeProject8", "SomeProject10", "requests", "FooProject9"} {
+		if _, ok := unpinned[name]; !ok {
+			t.Errorf("ParseRequirementsFile() missing unpinned requirement %v", name)
+		}
+	}
+	if req := unpinned["SomeProject10"]; req.Specifier != ">= 1.2" || req.Line != 39 {
+		t.Errorf("ParseRequirementsFile() continuation got = %+v", req)
+	}
+	if len(got.Warnings) != 7 {
+		t.Errorf("ParseRequirementsFile() len of warnings got = %v, want 7: %v", len(got.Warnings), got.Warnings)
+	}
+}
+
+func TestParseMavenEffectivePom(t *testing.T) {
+	data := `<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0.0</version>
+  <properties>
+    <guava.version>31.1-jre</guava.version>
+    <junit.version>${junit.major}.13.2</junit.version>
+    <junit.major>4</junit.major>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>com.google.guava</groupId>
+      <artifactId>guava</artifactId>
+      <version>${guava.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>${junit.version}</version>
+      <scope>test</scope>
+    </dependency>
+    <dependency>
+      <groupId>javax.servlet</groupId>
+      <artifactId>servlet-api</artifactId>
+      <version>2.5</version>
+      <scope>provided</scope>
+    </dependency>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>missing</artifactId>
+      <version>${missing.version}</version>
+    </dependency>
+  </dependencies>
+</project>`
+
+	tests := []struct {
+		name           string
+		productionOnly bool
+		want           []PackageDescriptor
+	}{
+		{
+			"all scopes",
+			false,
+			[]PackageDescriptor{
+				{Name: "com.google.guava:guava", Version: "31.1-jre", Type: Maven},
+				{Name: "junit:junit", Version: "4.13.2", Type: Maven},
+				{Name: "javax.servlet:servlet-api", Version: "2.5", Type: Maven},
+			},
+		},
+		{
+			"production only",
+			true,
+			[]PackageDescriptor{
+				{Name: "com.google.guava:guava", Version: "31.1-jre", Type: Maven},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMavenEffectivePom([]byte(data), tt.productionOnly)
+			if err != nil {
+				t.Fatalf("ParseMavenEffectivePom() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Packages, tt.want) {
+				t.Errorf("ParseMavenEffectivePom() got = %v, want %v", got.Packages, tt.want)
+			}
+			if len(got.Warnings) != 1 {
+				t.Errorf("ParseMavenEffectivePom() warnings got = %v, want 1 unresolved property", got.Warnings)
+			}
+		})
+	}
+}
+
+func TestParseGradleLockfile(t *testing.T) {
+	data := `# This is a Gradle generated file for dependency locking.
+com.google.guava:guava:23.3-jre=compileClasspath,runtimeClasspath
+junit:junit:4.13.2=testCompileClasspath,testRuntimeClasspath
+org.slf4j:slf4j-api:1.7.36=compileClasspath
+org.springframework:spring-core:5.2.15.RELEASE
+empty=annotationProcessor
+`
+	guava := PackageDescriptor{Name: "com.google.guava:guava", Version: "23.3-jre", Type: Maven}
+	junit := PackageDescriptor{Name: "junit:junit", Version: "4.13.2", Type: Maven}
+	slf4j := PackageDescriptor{Name: "org.slf4j:slf4j-api", Version: "1.7.36", Type: Maven}
+	spring := PackageDescriptor{Name: "org.springframework:spring-core", Version: "5.2.15.RELEASE", Type: Maven}
+
+	tests := []struct {
+		name    string
+		data    string
+		opts    *GradleParseOptions
+		want    []PackageDescriptor
+		wantErr bool
+	}{
+		{"all", data, nil, []PackageDescriptor{guava, junit, slf4j, spring}, false},
+		{"runtimeClasspath only", data, &GradleParseOptions{Configurations: []string{"runtimeClasspath"}}, []PackageDescriptor{guava}, false},
+		{"exclude test", data, &GradleParseOptions{ExcludeConfigurations: []string{"testCompileClasspath", "testRuntimeClasspath"}}, []PackageDescriptor{guava, slf4j, spring}, false},
+		{"production only", data, &GradleParseOptions{ProductionOnly: true}, []PackageDescriptor{guava, slf4j, spring}, false},
+		{"malformed", "com.google.guava:guava=classpath\n", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseGradleLockfile([]byte(tt.data), tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseGradleLockfile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseGradleLockfile() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNugetProject(t *testing.T) {
+	root := t.TempDir()
+	projectDir := filepath.Join(root, "src", "App")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	props := `<Project>
+  <ItemGroup>
+    <PackageVersion Include="Newtonsoft.Json" Version="13.0.1" />
+  </ItemGroup>
+</Project>`
+	csproj := `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <SerilogVersion>2.12.0</SerilogVersion>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageReference Include="Serilog" Version="$(SerilogVersion)" />
+    <PackageReference Include="newtonsoft.json" />
+    <PackageReference Include="Dapper" version="[2.0.123]" />
+    <PackageReference Include="Polly" Version="7.*" />
+    <PackageReference Include="Missing.Version" />
+    <PackageReference Include="Missing.Property" Version="$(Undefined)" />
+  </ItemGroup>
+  <ItemGroup Condition="'$(TargetFramework)' == 'net48'">
+    <PackageReference Include="System.ValueTuple">
+      <Version>4.5.0</Version>
+    </PackageReference>
+  </ItemGroup>
+</Project>`
+	if err := os.WriteFile(filepath.Join(root, "Directory.Packages.props"), []byte(props), 0644); err != nil {
+		t.Fatal(err)
+	}
+	projectPath := filepath.Join(projectDir, "App.csproj")
+	if err := os.WriteFile(projectPath, []byte(csproj), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseNugetProject(projectPath)
+	if err != nil {
+		t.Fatalf("ParseNugetProject() error = %v", err)
+	}
+	wantPackages := []PackageDescriptor{
+		{Name: "Serilog", Version: "2.12.0", Type: Nuget},
+		{Name: "newtonsoft.json", Version: "13.0.1", Type: Nuget},
+		{Name: "Dapper", Version: "2.0.123", Type: Nuget},
+		{Name: "System.ValueTuple", Version: "4.5.0", Type: Nuget},
+	}
+	if !reflect.DeepEqual(got.Packages, wantPackages) {
+		t.Errorf("ParseNugetProject() packages got = %v, want %v", got.Packages, wantPackages)
+	}
+	wantUnpinned := []UnpinnedRequirement{{Name: "Polly", Specifier: "7.*", File: projectPath, Line: 9}}
+	if !reflect.DeepEqual(got.Unpinned, wantUnpinned) {
+		t.Errorf("ParseNugetProject() unpinned got = %v, want %v", got.Unpinned, wantUnpinned)
+	}
+	if len(got.Warnings) != 2 {
+		t.Errorf("ParseNugetProject() warnings got = %v, want 2", got.Warnings)
+	}
+
+	lockfile := `{
+  "version": 1,
+  "dependencies": {
+    "net6.0": {
+      "Serilog": {"type": "Direct", "requested": "[2.12.0, )", "resolved": "2.12.0"},
+      "Lib": {"type": "Project"}
+    },
+    "net48": {
+      "Serilog": {"type": "Direct", "requested": "[2.12.0, )", "resolved": "2.12.0"},
+      "System.ValueTuple": {"type": "Transitive", "resolved": "4.5.0"}
+    }
+  }
+}`
+	if err := os.WriteFile(filepath.Join(projectDir, "packages.lock.json"), []byte(lockfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ParseNugetProject(projectPath)
+	if err != nil {
+		t.Fatalf("ParseNugetProject() error = %v", err)
+	}
+	wantPackages = []PackageDescriptor{
+		{Name: "Serilog", Version: "2.12.0", Type: Nuget},
+		{Name: "System.ValueTuple", Version: "4.5.0", Type: Nuget},
+	}
+	if !reflect.DeepEqual(got.Packages, wantPackages) {
+		t.Errorf("ParseNugetProject() with lockfile got = %v, want %v", got.Packages, wantPackages)
+	}
+	wantLines := map[PackageDescriptor]int{wantPackages[0]: 6, wantPackages[1]: 14}
+	if !reflect.DeepEqual(got.Lines, wantLines) {
+		t.Errorf("ParseNugetProject() with lockfile lines got = %v, want %v", got.Lines, wantLines)
+	}
+}
+
+func TestParseGemfileLock(t *testing.T) {
+	data := `GIT
+  remote: https://github.com/rails/rails.git
+  revision: 0123456789abcdef
+  specs:
+    rails (7.1.0.alpha)
+      railties (= 7.1.0.alpha)
+
+PATH
+  remote: engines/local
+  specs:
+    local_engine (0.1.0)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    nokogiri (1.13.1)
+      racc (~> 1.4)
+    nokogiri (1.13.1-x86_64-linux)
+      racc (~> 1.4)
+    racc (1.6.0)
+    railties (7.1.0.alpha)
+
+PLATFORMS
+  ruby
+  x86_64-linux
+
+DEPENDENCIES
+  local_engine!
+  nokogiri (~> 1.13)
+  rails!
+
+BUNDLED WITH
+   2.3.5
+`
+	lock, err := ParseGemfileLock([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseGemfileLock() error = %v", err)
+	}
+
+	if len(lock.Specs) != 6 {
+		t.Errorf("ParseGemfileLock() len of specs got = %v, want 6", len(lock.Specs))
+	}
+	if spec := lock.Specs[3]; spec.Version != "1.13.1" || spec.Platform != "x86_64-linux" || spec.Remote != "https://rubygems.org/" {
+		t.Errorf("ParseGemfileLock() platform spec got = %+v", spec)
+	}
+
+	wantAll := []PackageDescriptor{
+		{Name: "rails", Version: "7.1.0.alpha", Type: Rubygems},
+		{Name: "nokogiri", Version: "1.13.1", Type: Rubygems},
+		{Name: "racc", Version: "1.6.0", Type: Rubygems},
+		{Name: "railties", Version: "7.1.0.alpha", Type: Rubygems},
+	}
+	if got := lock.Packages(); !reflect.DeepEqual(got, wantAll) {
+		t.Errorf("Packages() got = %v, want %v", got, wantAll)
+	}
+
+	wantDirect := []PackageDescriptor{
+		{Name: "rails", Version: "7.1.0.alpha", Type: Rubygems},
+		{Name: "nokogiri", Version: "1.13.1", Type: Rubygems},
+	}
+	if got := lock.DirectPackages(); !reflect.DeepEqual(got, wantDirect) {
+		t.Errorf("DirectPackages() got = %v, want %v", got, wantDirect)
+	}
+
+	wantTransitive := []PackageDescriptor{
+		{Name: "racc", Version: "1.6.0", Type: Rubygems},
+		{Name: "railties", Version: "7.1.0.alpha", Type: Rubygems},
+	}
+	if got := lock.TransitivePackages(); !reflect.DeepEqual(got, wantTransitive) {
+		t.Errorf("TransitivePackages() got = %v, want %v", got, wantTransitive)
+	}
+}
+
+func TestParseLockfileWithOptionsUnpinned(t *testing.T) {
+	p := &PhylumClient{}
+	got, err := p.ParseLockfileWithOptions("test_lockfiles/Pipfile", &ParseOptions{Offline: true})
+	var unpinnedErr *UnpinnedRequirementsError
+	if !errors.As(err, &unpinnedErr) || unpinnedErr.Path != "test_lockfiles/Pipfile" {
+		t.Fatalf("ParseLockfileWithOptions() error = %v, want UnpinnedRequirementsError", err)
+	}
+	if got == nil || len(*got) != 4 {
+		t.Errorf("ParseLockfileWithOptions() should return the pinned packages with the error, got = %v", got)
+	}
+
+	got, err = p.ParseLockfileWithOptions("test_lockfiles/Pipfile", &ParseOptions{Offline: true, AllowUnpinned: true})
+	if err != nil || len(*got) != 4 {
+		t.Errorf("ParseLockfileWithOptions() with AllowUnpinned got = %v, %v", got, err)
+	}
+}
+
+// roundTripFunc serves requests of a resty client without a network
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestParseLockfileWithOptionsEffectivePom(t *testing.T) {
+	var uploads int
+	client := resty.New().SetTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		uploads++
+		body := `[{"name":"org.slf4j:slf4j-api","version":"1.7.36","type":"maven"}]`
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	}))
+	p := &PhylumClient{Client: client}
+
+	got, err := p.ParseLockfileWithOptions("test_lockfiles/effective-pom.xml", &ParseOptions{})
+	if err != nil {
+		t.Fatalf("ParseLockfileWithOptions() error = %v", err)
+	}
+	if want := []PackageDescriptor{{Name: "org.slf4j:slf4j-api", Version: "1.7.36", Type: Maven}}; uploads != 1 || !reflect.DeepEqual(*got, want) {
+		t.Errorf("ParseLockfileWithOptions() got = %v after %d uploads, want the parse service result", *got, uploads)
+	}
+
+	got, err = p.ParseLockfileWithOptions("test_lockfiles/effective-pom.xml", &ParseOptions{Offline: true})
+	if err != nil || len(*got) != 5 || uploads != 1 {
+		t.Errorf("ParseLockfileWithOptions() offline got = %v, %v after %d uploads", got, err, uploads)
+	}
+}
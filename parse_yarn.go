@@ -0,0 +1,382 @@
+package phylum
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yarnEntry is a resolved package block from either yarn lockfile format
+type yarnEntry struct {
+	Specifiers   []string
+	Name         string
+	Version      string
+	Dependencies map[string]string
+	Line         int
+	Patch        bool // Resolved through the Berry patch: protocol
+}
+
+// ParseYarnLockfile parses the contents of a yarn.lock. Both the classic v1 format and the
+// YAML based format written by yarn 2+ (Berry) are supported. Workspace and link entries
+// are skipped. Malformed v1 lockfiles return a *LockfileSyntaxError.
+func ParseYarnLockfile(data []byte) ([]PackageDescriptor, error) {
+	result, err := parseYarnLockfile(data)
+	if err != nil {
+		return nil, err
+	}
+	return result.Packages, nil
+}
+
+// parseYarnLockfile parses a yarn.lock, recording the line of the entry declaring each package
+func parseYarnLockfile(data []byte) (*ParseResult, error) {
+	var entries []yarnEntry
+	var err error
+
+	if isYarnBerry(data) {
+		entries, err = parseYarnBerry(data)
+	} else {
+		entries, err = parseYarnV1(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ParseResult{Lines: make(map[PackageDescriptor]int)}
+	for _, entry := range entries {
+		pkg := PackageDescriptor{Name: entry.Name, Version: entry.Version, Type: Npm}
+		result.Packages = append(result.Packages, pkg)
+		if _, ok := result.Lines[pkg]; !ok {
+			result.Lines[pkg] = entry.Line
+		}
+	}
+	return result, nil
+}
+
+// YarnDependencyGraph builds a dependency graph from the contents of a yarn.lock. Dependencies are
+// resolved through the specifiers each entry is keyed by. yarn.lock doesn't record which packages
+// the project requires itself, so packages nothing else depends on are treated as direct.
+func YarnDependencyGraph(data []byte) (*DependencyGraph, error) {
+	var entries []yarnEntry
+	var err error
+
+	if isYarnBerry(data) {
+		entries, err = parseYarnBerry(data)
+	} else {
+		entries, err = parseYarnV1(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	g := NewDependencyGraph()
+	bySpecifier := make(map[string]PackageDescriptor)
+	for _, entry := range entries {
+		pkg := PackageDescriptor{Name: entry.Name, Version: entry.Version, Type: Npm}
+		g.AddPackage(pkg, false)
+		for _, specifier := range entry.Specifiers {
+			bySpecifier[strings.Trim(specifier, `"`)] = pkg
+		}
+	}
+
+	for _, entry := range entries {
+		parent := PackageDescriptor{Name: entry.Name, Version: entry.Version, Type: Npm}
+		for _, name := range sortedKeys(entry.Dependencies) {
+			reference := entry.Dependencies[name]
+			child, ok := bySpecifier[name+"@"+reference]
+			if !ok {
+				// Berry keys registry ranges with an explicit npm: protocol
+				child, ok = bySpecifier[name+"@npm:"+reference]
+			}
+			if ok {
+				g.AddEdge(parent, child)
+			}
+		}
+	}
+	g.markRoots()
+	return g, nil
+}
+
+// isYarnBerry reports whether a yarn.lock uses the YAML format introduced in yarn 2
+func isYarnBerry(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("__metadata:")) || bytes.Contains(data, []byte("\n__metadata:"))
+}
+
+// yarnDescriptorName splits "name@range" into its name and range, honouring scoped names
+func yarnDescriptorName(descriptor string) (string, string) {
+	at := strings.Index(descriptor, "@")
+	if at == 0 {
+		next := strings.Index(descriptor[1:], "@")
+		if next < 0 {
+			return descriptor, ""
+		}
+		at = next + 1
+	}
+	if at < 0 {
+		return descriptor, ""
+	}
+	return descriptor[:at], descriptor[at+1:]
+}
+
+// yarnLocalProtocol reports whether a range or resolution points at local sources rather than a registry package
+func yarnLocalProtocol(reference string) bool {
+	for _, protocol := range []string{"workspace:", "link:", "portal:", "file:"} {
+		if strings.HasPrefix(reference, protocol) {
+			return true
+		}
+	}
+	return false
+}
+
+type yarnBerryEntry struct {
+	Version      string            `yaml:"version"`
+	Resolution   string            `yaml:"resolution"`
+	Dependencies map[string]string `yaml:"dependencies"`
+	LinkType     string            `yaml:"linkType"`
+}
+
+func parseYarnBerry(data []byte) ([]yarnEntry, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("ParseYarnLockfile(): failed to parse yaml: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("ParseYarnLockfile(): lockfile is not a yaml mapping")
+	}
+
+	var result []yarnEntry
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value == "__metadata" {
+			continue
+		}
+
+		var entry yarnBerryEntry
+		if err := value.Decode(&entry); err != nil {
+			return nil, &LockfileSyntaxError{Line: value.Line, Column: value.Column, Msg: err.Error()}
+		}
+		if entry.LinkType == "soft" {
+			continue
+		}
+
+		name, reference := yarnDescriptorName(entry.Resolution)
+		if name == "" || yarnLocalProtocol(reference) {
+			continue
+		}
+		if entry.Version == "" {
+			return nil, &LockfileSyntaxError{Line: key.Line, Column: key.Column, Msg: fmt.Sprintf("entry %q has no version", key.Value)}
+		}
+
+		specifiers := strings.Split(key.Value, ",")
+		for j := range specifiers {
+			specifiers[j] = strings.TrimSpace(specifiers[j])
+		}
+		result = append(result, yarnEntry{
+			Specifiers:   specifiers,
+			Name:         name,
+			Version:      entry.Version,
+			Dependencies: entry.Dependencies,
+			Line:         key.Line,
+			Patch:        strings.HasPrefix(reference, "patch:"),
+		})
+	}
+	return mergeYarnPatches(result), nil
+}
+
+// mergeYarnPatches folds patch entries into the entry of the package they patch, such as the
+// resolve and typescript patches yarn applies itself, so the package is only listed once. The
+// specifiers of the patch are kept so dependents of it still resolve. Patches of packages without
+// an entry of their own are kept.
+func mergeYarnPatches(entries []yarnEntry) []yarnEntry {
+	unpatched := make(map[PackageDescriptor]int)
+	var result []yarnEntry
+	for _, entry := range entries {
+		if !entry.Patch {
+			unpatched[PackageDescriptor{Name: entry.Name, Version: entry.Version, Type: Npm}] = len(result)
+			result = append(result, entry)
+		}
+	}
+	for _, entry := range entries {
+		if !entry.Patch {
+			continue
+		}
+		if i, ok := unpatched[PackageDescriptor{Name: entry.Name, Version: entry.Version, Type: Npm}]; ok {
+			result[i].Specifiers = append(result[i].Specifiers, entry.Specifiers...)
+		} else {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// yarnV1Token is a single key or value from a classic yarn.lock line
+type yarnV1Token struct {
+	Value  string
+	Column int
+}
+
+func parseYarnV1(data []byte) ([]yarnEntry, error) {
+	var result []yarnEntry
+	var current *yarnEntry
+	var section string
+	lineNum := 0
+
+	finish := func() error {
+		if current == nil {
+			return nil
+		}
+		if current.Version == "" {
+			return &LockfileSyntaxError{Line: current.Line, Column: 1, Msg: fmt.Sprintf("entry %q has no version", strings.Join(current.Specifiers, ", "))}
+		}
+		if current.Name != "" {
+			result = append(result, *current)
+		}
+		current = nil
+		return nil
+	}
+
+	// Every line is tokenized before any entry is validated so that syntax errors are reported
+	// at their position rather than as a missing field further up the file
+	type v1Line struct {
+		num    int
+		indent int
+		tokens []yarnV1Token
+		colon  bool
+	}
+	var lines []v1Line
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, &LockfileSyntaxError{Line: lineNum, Column: len(line) - len(trimmed) + 1, Msg: "tabs are not allowed for indentation"}
+		}
+
+		indent := len(line) - len(trimmed)
+		tokens, colon, err := tokenizeYarnV1Line(trimmed, lineNum, indent+1)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, v1Line{num: lineNum, indent: indent, tokens: tokens, colon: colon})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ParseYarnLockfile(): failed to read lockfile: %w", err)
+	}
+
+	for _, line := range lines {
+		switch line.indent {
+		case 0:
+			if !line.colon {
+				return nil, &LockfileSyntaxError{Line: line.num, Column: 1, Msg: "expected ':' after entry specifiers"}
+			}
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			current = &yarnEntry{Line: line.num, Dependencies: map[string]string{}}
+			for _, tok := range line.tokens {
+				current.Specifiers = append(current.Specifiers, tok.Value)
+			}
+			name, reference := yarnDescriptorName(current.Specifiers[0])
+			if strings.HasPrefix(reference, "npm:") {
+				name, _ = yarnDescriptorName(strings.TrimPrefix(reference, "npm:"))
+			}
+			if !yarnLocalProtocol(reference) {
+				current.Name = name
+			}
+			section = ""
+		case 2:
+			if current == nil {
+				return nil, &LockfileSyntaxError{Line: line.num, Column: 3, Msg: "field outside of an entry"}
+			}
+			if line.colon && len(line.tokens) == 1 {
+				section = line.tokens[0].Value
+				continue
+			}
+			section = ""
+			if len(line.tokens) != 2 {
+				return nil, &LockfileSyntaxError{Line: line.num, Column: 3, Msg: "expected a key and a value"}
+			}
+			if line.tokens[0].Value == "version" {
+				current.Version = line.tokens[1].Value
+			}
+		case 4:
+			if current == nil || section == "" {
+				return nil, &LockfileSyntaxError{Line: line.num, Column: 5, Msg: "unexpected indentation"}
+			}
+			if len(line.tokens) != 2 {
+				return nil, &LockfileSyntaxError{Line: line.num, Column: 5, Msg: "expected a key and a value"}
+			}
+			if section == "dependencies" || section == "optionalDependencies" {
+				current.Dependencies[line.tokens[0].Value] = line.tokens[1].Value
+			}
+		default:
+			return nil, &LockfileSyntaxError{Line: line.num, Column: line.indent + 1, Msg: "unexpected indentation"}
+		}
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// tokenizeYarnV1Line splits a line into quoted or bare tokens, dropping commas between entry
+// specifiers and reporting whether the line ends with a colon
+func tokenizeYarnV1Line(line string, lineNum int, startColumn int) ([]yarnV1Token, bool, error) {
+	var tokens []yarnV1Token
+	colon := false
+
+	for i := 0; i < len(line); {
+		column := startColumn + i
+		switch c := line[i]; {
+		case c == ' ' || c == ',':
+			i++
+		case c == ':' && i == len(line)-1:
+			colon = true
+			i++
+		case c == '"':
+			var sb strings.Builder
+			j := i + 1
+			closed := false
+			for j < len(line) {
+				if line[j] == '\\' && j+1 < len(line) {
+					sb.WriteByte(line[j+1])
+					j += 2
+					continue
+				}
+				if line[j] == '"' {
+					closed = true
+					break
+				}
+				sb.WriteByte(line[j])
+				j++
+			}
+			if !closed {
+				return nil, false, &LockfileSyntaxError{Line: lineNum, Column: column, Msg: "unterminated string"}
+			}
+			tokens = append(tokens, yarnV1Token{Value: sb.String(), Column: column})
+			i = j + 1
+		default:
+			j := i
+			for j < len(line) && line[j] != ' ' && line[j] != ',' && !(line[j] == ':' && j == len(line)-1) {
+				j++
+			}
+			tokens = append(tokens, yarnV1Token{Value: line[i:j], Column: column})
+			i = j
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil, false, &LockfileSyntaxError{Line: lineNum, Column: startColumn, Msg: "expected a key"}
+	}
+	return tokens, colon, nil
+}
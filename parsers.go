@@ -0,0 +1,355 @@
+package phylum
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrNoLockfileParser is returned when no registered parser recognizes a lockfile
+var ErrNoLockfileParser = errors.New("no local parser for lockfile")
+
+// sniffLength is how much of a lockfile is read for content detection
+const sniffLength = 8192
+
+// LockfileParser parses one lockfile format locally
+type LockfileParser interface {
+	// Name identifies the format, e.g. "npm" or "yarn-v1"
+	Name() string
+	// Detect reports whether the parser handles the file given its path and its first bytes
+	Detect(lockfilePath string, head []byte) bool
+	// Parse parses the full contents of the file at lockfilePath
+	Parse(lockfilePath string, data []byte, opts *ParseOptions) (*ParseResult, error)
+}
+
+// ParserRegistry selects a LockfileParser for a lockfile by name and content sniffing
+type ParserRegistry struct {
+	mu      sync.RWMutex
+	parsers []LockfileParser
+}
+
+// DefaultParserRegistry holds the built-in parsers and any registered with RegisterLockfileParser
+var DefaultParserRegistry = NewParserRegistry()
+
+// NewParserRegistry returns a registry containing the built-in parsers
+func NewParserRegistry() *ParserRegistry {
+	return &ParserRegistry{parsers: builtinLockfileParsers()}
+}
+
+// RegisterLockfileParser adds a parser to DefaultParserRegistry
+func RegisterLockfileParser(parser LockfileParser) {
+	DefaultParserRegistry.Register(parser)
+}
+
+// Register adds a parser to the registry. Registered parsers are consulted before the built-in
+// parsers, most recently registered first, so they can override detection of a built-in format.
+func (r *ParserRegistry) Register(parser LockfileParser) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parsers = append([]LockfileParser{parser}, r.parsers...)
+}
+
+// Parsers returns the registered parsers in the order they are consulted
+func (r *ParserRegistry) Parsers() []LockfileParser {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]LockfileParser(nil), r.parsers...)
+}
+
+// Detect returns the first parser that recognizes the lockfile, or ErrNoLockfileParser
+func (r *ParserRegistry) Detect(lockfilePath string) (LockfileParser, error) {
+	return detectLockfile(lockfilePath, r.Parsers())
+}
+
+// candidates returns the parsers that may handle a file named base, judging built-in parsers by
+// name alone so that files no parser could handle are never opened. Registered parsers are
+// always included.
+func (r *ParserRegistry) candidates(base string) []LockfileParser {
+	var parsers []LockfileParser
+	for _, parser := range r.Parsers() {
+		if f, ok := parser.(lockfileParserFunc); ok && !f.names(base) {
+			continue
+		}
+		parsers = append(parsers, parser)
+	}
+	return parsers
+}
+
+func detectLockfile(lockfilePath string, parsers []LockfileParser) (LockfileParser, error) {
+	head, err := readHead(lockfilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, parser := range parsers {
+		if parser.Detect(lockfilePath, head) {
+			return parser, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %v", ErrNoLockfileParser, lockfilePath)
+}
+
+// Parse detects the format of a lockfile and parses it
+func (r *ParserRegistry) Parse(lockfilePath string, opts *ParseOptions) (*ParseResult, error) {
+	if opts == nil {
+		opts = &ParseOptions{}
+	}
+
+	parser, err := r.Detect(lockfilePath)
+	if err != nil {
+		return nil, err
+	}
+	return parseWith(parser, lockfilePath, opts)
+}
+
+// parseWith reads lockfilePath and parses it with parser
+func parseWith(parser LockfileParser, lockfilePath string, opts *ParseOptions) (*ParseResult, error) {
+	data, err := os.ReadFile(lockfilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := parser.Parse(lockfilePath, data, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s parser: %w", parser.Name(), err)
+	}
+	return result, nil
+}
+
+func readHead(lockfilePath string) ([]byte, error) {
+	file, err := os.Open(lockfilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("lockfilePath: %v is not a file", lockfilePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// lockfileParserFunc adapts a detection and a parse function into a LockfileParser
+type lockfileParserFunc struct {
+	name   string
+	names  func(base string) bool // Whether a file name can be this format, before sniffing
+	detect func(base string, head []byte) bool
+	parse  func(lockfilePath string, data []byte, opts *ParseOptions) (*ParseResult, error)
+	locate func(data []byte) (map[PackageDescriptor]int, error) // For formats whose parse doesn't track lines
+}
+
+func (f lockfileParserFunc) Name() string { return f.name }
+
+func (f lockfileParserFunc) Detect(lockfilePath string, head []byte) bool {
+	return f.detect(filepath.Base(lockfilePath), head)
+}
+
+func (f lockfileParserFunc) Parse(lockfilePath string, data []byte, opts *ParseOptions) (*ParseResult, error) {
+	return f.parse(lockfilePath, data, opts)
+}
+
+func (f lockfileParserFunc) Locate(data []byte) (map[PackageDescriptor]int, error) {
+	if f.locate == nil {
+		return nil, nil
+	}
+	return f.locate(data)
+}
+
+// packagesOnly wraps a parser that cannot report unpinned requirements
+func packagesOnly(parse func(data []byte) ([]PackageDescriptor, error)) func(string, []byte, *ParseOptions) (*ParseResult, error) {
+	return func(_ string, data []byte, _ *ParseOptions) (*ParseResult, error) {
+		packages, err := parse(data)
+		if err != nil {
+			return nil, err
+		}
+		return &ParseResult{Packages: packages}, nil
+	}
+}
+
+// hasSuffix returns a name filter matching any of the suffixes
+func hasSuffix(suffixes ...string) func(base string) bool {
+	return func(base string) bool {
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(base, suffix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func isJSONObject(head []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(head), []byte("{"))
+}
+
+// isEffectivePom reports whether a file is the output of mvn help:effective-pom. A plain pom.xml
+// leaves versions to properties and parents that only Maven resolves, so it is left to the
+// Phylum parse service.
+func isEffectivePom(base string, head []byte) bool {
+	if !strings.HasSuffix(base, ".xml") || !bytes.Contains(head, []byte("<project")) {
+		return false
+	}
+	return strings.Contains(base, "effective-pom") || bytes.Contains(head, []byte("<projects")) ||
+		bytes.Contains(head, []byte("Generated by Maven Help Plugin"))
+}
+
+// builtinLockfileParsers returns the built-in parsers. Parsers that sniff content come before
+// those that only look at the file name so that a misnamed file is still detected correctly.
+func builtinLockfileParsers() []LockfileParser {
+	return []LockfileParser{
+		lockfileParserFunc{
+			name:  "pipfile-lock",
+			names: hasSuffix(".lock", ".json"),
+			detect: func(base string, head []byte) bool {
+				return isJSONObject(head) && bytes.Contains(head, []byte(`"_meta"`)) &&
+					(base == "Pipfile.lock" || bytes.Contains(head, []byte(`"pipfile-spec"`)))
+			},
+			parse:  packagesOnly(ParsePipfileLock),
+			locate: locatePipfileLock,
+		},
+		lockfileParserFunc{
+			name:  "npm",
+			names: hasSuffix(".json"),
+			detect: func(base string, head []byte) bool {
+				if strings.HasPrefix(base, "package-lock") && strings.HasSuffix(base, ".json") || base == "npm-shrinkwrap.json" {
+					return true
+				}
+				return strings.HasSuffix(base, ".json") && isJSONObject(head) && bytes.Contains(head, []byte(`"lockfileVersion"`))
+			},
+			parse:  packagesOnly(ParseNpmLockfile),
+			locate: locateNpmLockfile,
+		},
+		lockfileParserFunc{
+			name:  "nuget-lock",
+			names: hasSuffix(".json"),
+			detect: func(base string, head []byte) bool {
+				if base == "packages.lock.json" {
+					return true
+				}
+				return strings.HasSuffix(base, ".json") && isJSONObject(head) && bytes.Contains(head, []byte(`"resolved"`)) &&
+					(bytes.Contains(head, []byte(`"Direct"`)) || bytes.Contains(head, []byte(`"Transitive"`)))
+			},
+			parse:  packagesOnly(ParseNugetLockfile),
+			locate: locateNugetLockfile,
+		},
+		lockfileParserFunc{
+			name: "yarn-berry",
+			names: func(base string) bool {
+				return strings.HasPrefix(base, "yarn") || strings.HasSuffix(base, ".lock")
+			},
+			detect: func(base string, head []byte) bool {
+				return isYarnBerry(head) && (strings.HasPrefix(base, "yarn") || strings.HasSuffix(base, ".lock"))
+			},
+			parse: func(_ string, data []byte, _ *ParseOptions) (*ParseResult, error) {
+				return parseYarnLockfile(data)
+			},
+		},
+		lockfileParserFunc{
+			name: "yarn-v1",
+			names: func(base string) bool {
+				return strings.HasPrefix(base, "yarn") || strings.HasSuffix(base, ".lock")
+			},
+			detect: func(base string, head []byte) bool {
+				if isYarnBerry(head) {
+					return false
+				}
+				return bytes.Contains(head, []byte("# yarn lockfile v1")) || strings.HasPrefix(base, "yarn") && strings.Contains(base, ".lock")
+			},
+			parse: func(_ string, data []byte, _ *ParseOptions) (*ParseResult, error) {
+				return parseYarnLockfile(data)
+			},
+		},
+		lockfileParserFunc{
+			name:  "poetry",
+			names: hasSuffix(".lock"),
+			detect: func(base string, head []byte) bool {
+				return base == "poetry.lock" || strings.HasSuffix(base, ".lock") && bytes.HasPrefix(bytes.TrimSpace(head), []byte("[[package]]"))
+			},
+			parse: packagesOnly(ParsePoetryLock),
+		},
+		lockfileParserFunc{
+			name:  "pipfile",
+			names: hasSuffix("Pipfile"),
+			detect: func(base string, head []byte) bool {
+				return base == "Pipfile" && !isJSONObject(head)
+			},
+			parse: func(_ string, data []byte, _ *ParseOptions) (*ParseResult, error) {
+				return ParsePipfile(data)
+			},
+		},
+		lockfileParserFunc{
+			name:  "requirements",
+			names: hasSuffix(".txt"),
+			detect: func(base string, head []byte) bool {
+				return strings.HasSuffix(base, ".txt") && strings.Contains(base, "requirements")
+			},
+			parse: func(lockfilePath string, _ []byte, _ *ParseOptions) (*ParseResult, error) {
+				return ParseRequirementsFile(lockfilePath)
+			},
+		},
+		lockfileParserFunc{
+			name:  "gemfile",
+			names: hasSuffix(".lock", ".locked"),
+			detect: func(base string, head []byte) bool {
+				if base == "Gemfile.lock" || base == "gems.locked" {
+					return true
+				}
+				for _, section := range []string{"GEM\n", "GIT\n", "PATH\n"} {
+					if bytes.HasPrefix(head, []byte(section)) {
+						return strings.HasSuffix(base, ".lock") || strings.HasSuffix(base, ".locked")
+					}
+				}
+				return false
+			},
+			parse: func(_ string, data []byte, _ *ParseOptions) (*ParseResult, error) {
+				lock, err := ParseGemfileLock(data)
+				if err != nil {
+					return nil, err
+				}
+				return &ParseResult{Packages: lock.Packages(), Lines: lock.lines()}, nil
+			},
+		},
+		lockfileParserFunc{
+			name:  "gradle",
+			names: hasSuffix(".lockfile"),
+			detect: func(base string, head []byte) bool {
+				return base == "gradle.lockfile" || strings.HasSuffix(base, ".lockfile") && bytes.Contains(head, []byte("Gradle generated file"))
+			},
+			parse: func(_ string, data []byte, opts *ParseOptions) (*ParseResult, error) {
+				return parseGradleLockfile(data, &GradleParseOptions{
+					Configurations:        opts.Configurations,
+					ExcludeConfigurations: opts.ExcludeConfigurations,
+					ProductionOnly:        opts.ProductionOnly,
+				})
+			},
+		},
+		lockfileParserFunc{
+			name:   "maven",
+			names:  hasSuffix(".xml"),
+			detect: isEffectivePom,
+			parse: func(_ string, data []byte, opts *ParseOptions) (*ParseResult, error) {
+				return ParseMavenEffectivePom(data, opts.ProductionOnly)
+			},
+		},
+		lockfileParserFunc{
+			name:  "nuget-project",
+			names: hasSuffix(".csproj", ".fsproj", ".vbproj"),
+			detect: func(base string, head []byte) bool {
+				return strings.HasSuffix(base, ".csproj") || strings.HasSuffix(base, ".fsproj") || strings.HasSuffix(base, ".vbproj")
+			},
+			parse: func(lockfilePath string, _ []byte, _ *ParseOptions) (*ParseResult, error) {
+				return ParseNugetProject(lockfilePath)
+			},
+		},
+	}
+}
@@ -0,0 +1,148 @@
+package phylum
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParserRegistry_Detect(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name         string
+		lockfilePath string
+		want         string
+		wantErr      bool
+	}{
+		{"package-lock", "test_lockfiles/package-lock.json", "npm", false},
+		{"package-lock-v6", "test_lockfiles/package-lock-v6.json", "npm", false},
+		{"yarn berry", "test_lockfiles/yarn.lock", "yarn-berry", false},
+		{"yarn v1", "test_lockfiles/yarn-v1.lock", "yarn-v1", false},
+		{"pipfile.lock", "test_lockfiles/Pipfile.lock", "pipfile-lock", false},
+		{"pipfile", "test_lockfiles/Pipfile", "pipfile", false},
+		{"poetry", "test_lockfiles/poetry.lock", "poetry", false},
+		{"requirements", "test_lockfiles/requirements.txt", "requirements", false},
+		{"csproj", "test_lockfiles/Calculator.csproj", "nuget-project", false},
+		{"effective-pom", "test_lockfiles/effective-pom.xml", "maven", false},
+		{"gradle", "test_lockfiles/gradle.lockfile", "gradle", false},
+		{"gemfile", "test_lockfiles/Gemfile.lock", "gemfile", false},
+		{"renamed npm", write("frontend.json", `{"name": "app", "lockfileVersion": 3, "packages": {}}`), "npm", false},
+		{"renamed berry", write("deps.lock", "__metadata:\n  version: 6\n"), "yarn-berry", false},
+		{"renamed yarn v1", write("deps-v1.lock", "# THIS IS AN AUTOGENERATED FILE.\n# yarn lockfile v1\n"), "yarn-v1", false},
+		{"renamed pipfile.lock", write("locked.json", `{"_meta": {"pipfile-spec": 6}, "default": {}}`), "pipfile-lock", false},
+		{"nuget lock", write("packages.lock.json", `{"version": 1, "dependencies": {}}`), "nuget-lock", false},
+		{"renamed effective pom", write("resolved.xml", "<?xml version=\"1.0\"?>\n<!-- Generated by Maven Help Plugin -->\n<project>\n</project>\n"), "maven", false},
+		{"plain pom", write("pom.xml", "<?xml version=\"1.0\"?>\n<project>\n  <version>${revision}</version>\n</project>\n"), "", true},
+		{"ant build", write("build.xml", "<?xml version=\"1.0\"?>\n<project name=\"app\" default=\"jar\">\n</project>\n"), "", true},
+		{"unknown", write("notes.txt", "hello"), "", true},
+		{"missing", filepath.Join(dir, "missing.lock"), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewParserRegistry().Detect(tt.lockfilePath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Detect() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.Name() != tt.want {
+				t.Errorf("Detect() got = %v, want %v", got.Name(), tt.want)
+			}
+			if _, err := detectLockfile(tt.lockfilePath, NewParserRegistry().candidates(filepath.Base(tt.lockfilePath))); err != nil {
+				t.Errorf("candidates() filtered out %v: %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := NewParserRegistry().Detect(filepath.Join(dir, "notes.txt")); !errors.Is(err, ErrNoLockfileParser) {
+		t.Errorf("Detect() error = %v, want ErrNoLockfileParser", err)
+	}
+}
+
+func TestParserRegistry_candidates(t *testing.T) {
+	registry := NewParserRegistry()
+	tests := []struct {
+		name string
+		base string
+		want int
+	}{
+		{"source", "main.go", 0},
+		{"readme", "README.md", 0},
+		{"image", "logo.png", 0},
+		{"lock", "yarn.lock", 5},
+		{"json", "package.json", 3},
+		{"requirements", "requirements-dev.txt", 1},
+		{"pipfile", "Pipfile", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := registry.candidates(tt.base); len(got) != tt.want {
+				t.Errorf("candidates() got = %v parsers, want %v", len(got), tt.want)
+			}
+		})
+	}
+
+	registry.Register(inhouseParser{})
+	if got := registry.candidates("main.go"); len(got) != 1 || got[0].Name() != "inhouse" {
+		t.Errorf("candidates() got = %v, want the registered parser", got)
+	}
+}
+
+// inhouseParser reads "name@version" lines from *.deps files
+type inhouseParser struct{}
+
+func (inhouseParser) Name() string { return "inhouse" }
+
+func (inhouseParser) Detect(lockfilePath string, _ []byte) bool {
+	return strings.HasSuffix(lockfilePath, ".deps")
+}
+
+func (inhouseParser) Parse(_ string, data []byte, _ *ParseOptions) (*ParseResult, error) {
+	result := &ParseResult{}
+	for _, line := range strings.Fields(string(data)) {
+		name, version, _ := strings.Cut(line, "@")
+		result.Packages = append(result.Packages, PackageDescriptor{Name: name, Version: version, Type: Npm})
+	}
+	return result, nil
+}
+
+func TestParserRegistry_Register(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.deps")
+	if err := os.WriteFile(path, []byte("left-pad@1.3.0\nlodash@4.17.21\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := NewParserRegistry()
+	if _, err := registry.Parse(path, nil); !errors.Is(err, ErrNoLockfileParser) {
+		t.Fatalf("Parse() before Register error = %v, want ErrNoLockfileParser", err)
+	}
+
+	registry.Register(inhouseParser{})
+	got, err := registry.Parse(path, nil)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(got.Packages) != 2 {
+		t.Errorf("Parse(): len of parsed packages: got = %v, want 2", len(got.Packages))
+	}
+	if registry.Parsers()[0].Name() != "inhouse" {
+		t.Errorf("Parsers(): registered parser should be consulted first")
+	}
+
+	// Registering on one registry must not leak into the default registry
+	if _, err := DefaultParserRegistry.Detect(path); !errors.Is(err, ErrNoLockfileParser) {
+		t.Errorf("DefaultParserRegistry.Detect() error = %v, want ErrNoLockfileParser", err)
+	}
+}
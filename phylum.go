@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"golang.org/x/sync/semaphore"
+	"net/http"
 	"os"
 	"reflect"
 	"strings"
@@ -45,9 +46,14 @@ func CheckResponse(resp *resty.Response) *string {
 }
 
 type ClientOptions struct {
-	Token    string // Phylum token
-	ApiHost  string // Phylum API Hostname
-	ApiNoTLS bool   // Disable TLS to Phylum API endpoint
+	Token           string // Phylum token
+	ApiHost         string // Phylum API Hostname
+	ApiNoTLS        bool   // Disable TLS to Phylum API endpoint
+	GzipRequests    bool   // Gzip job submission bodies
+	MaxPayloadBytes int    // Reject job submissions larger than this many bytes before sending; 0 disables the check
+
+	DisableRemoteParse bool            // Never upload lockfiles to the Phylum parse service
+	Parsers            *ParserRegistry // Local lockfile parsers, DefaultParserRegistry when nil
 }
 
 type PhylumClient struct {
@@ -58,6 +64,12 @@ type PhylumClient struct {
 	Groups       ListUserGroupsResponse
 	AllProjects  []ProjectSummaryResponse
 	ApiUrl       string
+
+	GzipRequests    bool
+	MaxPayloadBytes int
+
+	DisableRemoteParse bool
+	Parsers            *ParserRegistry
 }
 
 func NewClient(opts *ClientOptions) (*PhylumClient, error) {
@@ -91,6 +103,12 @@ func NewClient(opts *ClientOptions) (*PhylumClient, error) {
 		Client:       client,
 		ApiUrl:       apiUrl,
 	}
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		pClient.GzipRequests = opts.GzipRequests
+		pClient.MaxPayloadBytes = opts.MaxPayloadBytes
+		pClient.DisableRemoteParse = opts.DisableRemoteParse
+		pClient.Parsers = opts.Parsers
+	}
 	if err = pClient.GetAccessToken(); err != nil {
 		return nil, fmt.Errorf("Failed to get access token: %v\n", err)
 	}
@@ -649,10 +667,6 @@ func (p *PhylumClient) GetAllGroupProjectsByEcosystem(groupName string, ecosyste
 }
 
 func (p *PhylumClient) AnalyzeParsedPackages(projectType string, projectID string, packages *[]PackageDescriptor) (string, error) {
-	var respSPR SubmitPackageResponse
-	//var url string = "https://api.phylum.io/api/v0/data/jobs"
-	url := fmt.Sprintf("%s/data/jobs", p.ApiUrl)
-
 	submitPackageRequest := SubmitPackageRequest{
 		GroupName: nil,
 		IsUser:    true,
@@ -662,10 +676,29 @@ func (p *PhylumClient) AnalyzeParsedPackages(projectType string, projectID strin
 		Type:      projectType,
 	}
 
+	return p.submitPackages(submitPackageRequest)
+}
+
+// submitPackages posts a SubmitPackageRequest to the jobs endpoint and returns the resulting job ID
+func (p *PhylumClient) submitPackages(submitPackageRequest SubmitPackageRequest) (string, error) {
+	var respSPR SubmitPackageResponse
+	//var url string = "https://api.phylum.io/api/v0/data/jobs"
+	url := fmt.Sprintf("%s/data/jobs", p.ApiUrl)
+
+	submitPackageRequest.Packages = DedupePackages(submitPackageRequest.Packages)
+	body, headers, err := p.encodeSubmitBody(submitPackageRequest)
+	if err != nil {
+		return "", err
+	}
+
 	resp, err := p.Client.R().
 		SetAuthToken(p.OauthToken.AccessToken).
-		SetBody(submitPackageRequest).
+		SetHeaders(headers).
+		SetBody(body).
 		Post(url)
+	if resp != nil && resp.StatusCode() == http.StatusRequestEntityTooLarge {
+		return "", &PayloadTooLargeError{Size: len(body), Packages: len(submitPackageRequest.Packages)}
+	}
 	test := CheckResponse(resp)
 	if test != nil || err != nil {
 		fmt.Printf("failed to analyze packages\n")
@@ -709,8 +742,15 @@ func (p *PhylumClient) GetJobVerbose(jobID string) (*JobStatusResponseForPackage
 
 // ParseLockfile parses a lockfile into a struct that can be submitted for analysis.
 // It takes the path to a lockfile as input, and returns a pointer to a slice of PackageDescriptors
-// This method uses an online service from Phylum to parse packages and requires access to the Internet
+// Lockfiles are parsed locally when a registered parser recognizes the format. Otherwise the
+// online parse service from Phylum is used, which requires access to the Internet, unless the
+// client was created with DisableRemoteParse.
 func (p *PhylumClient) ParseLockfile(lockfilePath string) (*[]PackageDescriptor, error) {
+	return p.ParseLockfileWithOptions(lockfilePath, &ParseOptions{Offline: p.DisableRemoteParse})
+}
+
+// parseLockfileRemote parses a lockfile with the online service from Phylum
+func (p *PhylumClient) parseLockfileRemote(lockfilePath string) (*[]PackageDescriptor, error) {
 	if _, err := os.Stat(lockfilePath); errors.Is(err, os.ErrNotExist) {
 		return nil, fmt.Errorf("lockfilePath: %v is not a file", lockfilePath)
 	}
@@ -0,0 +1,356 @@
+package phylum
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Policy rule names reported in PolicyViolation.Rule
+const (
+	PolicyRuleThreshold   = "threshold"
+	PolicyRuleDeny        = "deny"
+	PolicyRuleMinScore    = "min_score"
+	PolicyRuleRiskLevel   = "risk_level"
+	PolicyRuleAbandonware = "abandonware"
+)
+
+// PackageRule matches packages by name and optionally by ecosystem and version
+type PackageRule struct {
+	Name     string
+	Type     PackageType  // Matches any ecosystem when empty
+	Version  string       // Exact version, matches any version when empty
+	Versions VersionRange // Version range compared in the package's ecosystem, checked in addition to Version
+	Reason   string
+}
+
+// IssueSuppression silences an issue, by tag or ID, until it expires
+type IssueSuppression struct {
+	Tag     string        // Issue tag, such as HL00001
+	Id      string        // Issue ID, used when Tag is empty
+	Package string        // Limits the suppression to one package when set
+	Reason  string        // Why the issue is suppressed
+	Kind    IgnoredReason // Category reported to Phylum, Other when empty
+	Expires time.Time     // Never expires when zero
+}
+
+// PolicyRules are organization rules evaluated locally in addition to the project thresholds.
+// Rule actions default to ThresholdViolationActionBreak when empty.
+type PolicyRules struct {
+	AllowPackages []PackageRule // Packages exempt from every rule and threshold
+	Suppressions  []IssueSuppression
+
+	DenyPackages []PackageRule
+	DenyAction   ThresholdViolationAction
+
+	MinPackageScore       float64 // Packages scoring below this are violations, 0 disables the rule
+	MinPackageScoreAction ThresholdViolationAction
+
+	// Issues in a domain at or above the given level are violations
+	ForbiddenRiskLevels      map[RiskDomain]RiskLevel
+	ForbiddenRiskLevelAction ThresholdViolationAction
+
+	MaxAbandonware    *int // Maximum number of abandoned packages, nil disables the rule
+	AbandonwareAction ThresholdViolationAction
+}
+
+// PolicyViolation is a single rule broken by a package
+type PolicyViolation struct {
+	Rule      string
+	Domain    RiskDomain // Set for threshold and risk level violations, "total" for the total threshold
+	Package   PackageDescriptor
+	Score     float64 // Score that breached a threshold or minimum score
+	Threshold float64
+	Issue     *Issue // Issue that breached a risk level rule
+	Action    ThresholdViolationAction
+	Message   string
+}
+
+// PolicyVerdict is the outcome of evaluating a job against a policy
+type PolicyVerdict struct {
+	Action     ThresholdViolationAction // Most severe action of any violation
+	Violations []PolicyViolation
+}
+
+// Pass reports whether no violation requires breaking the build
+func (v *PolicyVerdict) Pass() bool {
+	return v.Action != ThresholdViolationActionBreak
+}
+
+// ViolationsWithAction returns the violations that carry the given action
+func (v *PolicyVerdict) ViolationsWithAction(action ThresholdViolationAction) []PolicyViolation {
+	var result []PolicyViolation
+	for _, violation := range v.Violations {
+		if violation.Action == action {
+			result = append(result, violation)
+		}
+	}
+	return result
+}
+
+// EvaluatePolicy evaluates the packages of a verbose job against the project thresholds and
+// any additional rules. Either thresholds or rules may be nil. Inactive thresholds are skipped,
+// and issues with an unexpired suppression neither trigger risk level rules nor count as abandonware.
+func EvaluatePolicy(job *JobStatusResponseForPackageStatusExtended, thresholds *RiskThresholds, rules *PolicyRules) *PolicyVerdict {
+	verdict := &PolicyVerdict{Action: ThresholdViolationActionNone}
+	if job == nil {
+		return verdict
+	}
+	if rules == nil {
+		rules = &PolicyRules{}
+	}
+
+	now := time.Now()
+	var abandoned []PackageStatusExtended
+	for _, status := range job.Packages {
+		pkg := PackageDescriptor{Name: status.Name, Version: status.Version, Type: jobPackageType(status.Type)}
+		if matchesAnyRule(rules.AllowPackages, pkg) {
+			continue
+		}
+		status.Issues = unsuppressedIssues(status.Issues, pkg, rules.Suppressions, now)
+
+		if thresholds != nil {
+			evaluateThresholds(verdict, pkg, status, thresholds)
+		}
+
+		for _, rule := range rules.DenyPackages {
+			if !rule.Matches(pkg) {
+				continue
+			}
+			message := fmt.Sprintf("%s@%s is denied", pkg.Name, pkg.Version)
+			if rule.Reason != "" {
+				message += ": " + rule.Reason
+			}
+			verdict.add(PolicyViolation{Rule: PolicyRuleDeny, Package: pkg, Action: defaultAction(rules.DenyAction), Message: message})
+			break
+		}
+
+		if rules.MinPackageScore > 0 && status.PackageScore != nil && *status.PackageScore < rules.MinPackageScore {
+			verdict.add(PolicyViolation{
+				Rule:      PolicyRuleMinScore,
+				Package:   pkg,
+				Score:     *status.PackageScore,
+				Threshold: rules.MinPackageScore,
+				Action:    defaultAction(rules.MinPackageScoreAction),
+				Message:   fmt.Sprintf("package score %.2f is below minimum %.2f", *status.PackageScore, rules.MinPackageScore),
+			})
+		}
+
+		for i := range status.Issues {
+			issue := status.Issues[i]
+			level, ok := rules.ForbiddenRiskLevels[issue.Domain]
+			if !ok || RiskLevelSeverity(issue.Severity) < RiskLevelSeverity(level) {
+				continue
+			}
+			verdict.add(PolicyViolation{
+				Rule:    PolicyRuleRiskLevel,
+				Domain:  issue.Domain,
+				Package: pkg,
+				Issue:   &issue,
+				Action:  defaultAction(rules.ForbiddenRiskLevelAction),
+				Message: fmt.Sprintf("%s %s issue: %s", issue.Severity, issue.Domain, issue.Title),
+			})
+		}
+
+		if IsAbandonware(status) {
+			abandoned = append(abandoned, status)
+		}
+	}
+
+	if rules.MaxAbandonware != nil && len(abandoned) > *rules.MaxAbandonware {
+		for _, status := range abandoned {
+			verdict.add(PolicyViolation{
+				Rule:      PolicyRuleAbandonware,
+				Package:   PackageDescriptor{Name: status.Name, Version: status.Version, Type: jobPackageType(status.Type)},
+				Threshold: float64(*rules.MaxAbandonware),
+				Action:    defaultAction(rules.AbandonwareAction),
+				Message:   fmt.Sprintf("%d abandoned packages exceed the maximum of %d", len(abandoned), *rules.MaxAbandonware),
+			})
+		}
+	}
+
+	return verdict
+}
+
+func evaluateThresholds(verdict *PolicyVerdict, pkg PackageDescriptor, status PackageStatusExtended, thresholds *RiskThresholds) {
+	if status.PackageScore != nil && thresholds.Total.Active && *status.PackageScore < float64(thresholds.Total.Threshold) {
+		verdict.add(PolicyViolation{
+			Rule:      PolicyRuleThreshold,
+			Domain:    "total",
+			Package:   pkg,
+			Score:     *status.PackageScore,
+			Threshold: float64(thresholds.Total.Threshold),
+			Action:    thresholds.Total.Action,
+			Message:   fmt.Sprintf("total score %.2f is below threshold %.2f", *status.PackageScore, thresholds.Total.Threshold),
+		})
+	}
+
+	for _, key := range sortedKeys(status.RiskVectors.AdditionalProperties) {
+		domain, ok := riskVectorDomain(key)
+		if !ok {
+			continue
+		}
+		threshold := thresholdForDomain(thresholds, domain)
+		score := status.RiskVectors.AdditionalProperties[key]
+		if !threshold.Active || score >= float64(threshold.Threshold) {
+			continue
+		}
+		verdict.add(PolicyViolation{
+			Rule:      PolicyRuleThreshold,
+			Domain:    domain,
+			Package:   pkg,
+			Score:     score,
+			Threshold: float64(threshold.Threshold),
+			Action:    threshold.Action,
+			Message:   fmt.Sprintf("%s score %.2f is below threshold %.2f", domain, score, threshold.Threshold),
+		})
+	}
+}
+
+func matchesAnyRule(rules []PackageRule, pkg PackageDescriptor) bool {
+	for _, rule := range rules {
+		if rule.Matches(pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+func unsuppressedIssues(issues []Issue, pkg PackageDescriptor, suppressions []IssueSuppression, now time.Time) []Issue {
+	if len(suppressions) == 0 {
+		return issues
+	}
+	var result []Issue
+	for _, issue := range issues {
+		suppressed := false
+		for _, suppression := range suppressions {
+			if suppression.Suppresses(issue, pkg, now) {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			result = append(result, issue)
+		}
+	}
+	return result
+}
+
+// Suppresses reports whether the suppression applies to an issue of pkg at the given time
+func (s IssueSuppression) Suppresses(issue Issue, pkg PackageDescriptor, now time.Time) bool {
+	if !s.Expires.IsZero() && now.After(s.Expires) {
+		return false
+	}
+	if s.Package != "" && !(PackageRule{Name: s.Package}).Matches(pkg) {
+		return false
+	}
+	if s.Tag != "" {
+		return issue.Tag != nil && *issue.Tag == s.Tag
+	}
+	return s.Id != "" && issue.Id != nil && *issue.Id == s.Id
+}
+
+func (v *PolicyVerdict) add(violation PolicyViolation) {
+	if violation.Action == "" {
+		violation.Action = ThresholdViolationActionNone
+	}
+	v.Violations = append(v.Violations, violation)
+	if actionSeverity(Action(violation.Action)) > actionSeverity(Action(v.Action)) {
+		v.Action = violation.Action
+	}
+}
+
+// Matches reports whether the rule applies to pkg. Names are compared case-insensitively, and
+// PyPI names after PEP 503 normalization.
+func (r PackageRule) Matches(pkg PackageDescriptor) bool {
+	if r.Type != "" && r.Type != pkg.Type {
+		return false
+	}
+	if r.Version != "" && r.Version != pkg.Version {
+		return false
+	}
+	if !r.Versions.Contains(pkg.Type, pkg.Version) {
+		return false
+	}
+	if pkg.Type == Pypi {
+		return normalizePythonName(r.Name) == normalizePythonName(pkg.Name)
+	}
+	return strings.EqualFold(r.Name, pkg.Name)
+}
+
+// abandonwareTags are the tags of author issues that flag a package as abandoned
+var abandonwareTags = []string{"abandoned", "abandonware"}
+
+// IsAbandonware reports whether the package has an author issue tagged as abandoned. Titles and
+// descriptions are not considered, as other issues can mention abandoned code.
+func IsAbandonware(status PackageStatusExtended) bool {
+	for _, issue := range status.Issues {
+		if issue.Domain != RiskDomainAuthor || issue.Tag == nil {
+			continue
+		}
+		for _, tag := range abandonwareTags {
+			if strings.EqualFold(strings.TrimSpace(*issue.Tag), tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RiskLevelSeverity orders risk levels from Info (0) to Critical (4). Unknown levels order below Info.
+func RiskLevelSeverity(level RiskLevel) int {
+	switch level {
+	case Critical:
+		return 4
+	case High:
+		return 3
+	case Medium:
+		return 2
+	case Low:
+		return 1
+	case Info:
+		return 0
+	default:
+		return -1
+	}
+}
+
+// riskVectorDomain maps a risk vector key from a job to its RiskDomain
+func riskVectorDomain(key string) (RiskDomain, bool) {
+	switch key {
+	case "author":
+		return RiskDomainAuthor, true
+	case "engineering":
+		return RiskDomainEngineering, true
+	case "license":
+		return RiskDomainLicense, true
+	case "malicious_code", "malicious":
+		return RiskDomainMaliciousCode, true
+	case "vulnerability", "vulnerabilities":
+		return RiskDomainVulnerability, true
+	}
+	return "", false
+}
+
+func thresholdForDomain(thresholds *RiskThresholds, domain RiskDomain) ThresholdDescriptor {
+	switch domain {
+	case RiskDomainAuthor:
+		return thresholds.Author
+	case RiskDomainEngineering:
+		return thresholds.Engineering
+	case RiskDomainLicense:
+		return thresholds.License
+	case RiskDomainMaliciousCode:
+		return thresholds.MaliciousCode
+	case RiskDomainVulnerability:
+		return thresholds.Vulnerability
+	}
+	return ThresholdDescriptor{}
+}
+
+func defaultAction(action ThresholdViolationAction) ThresholdViolationAction {
+	if action == "" {
+		return ThresholdViolationActionBreak
+	}
+	return action
+}
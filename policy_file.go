@@ -0,0 +1,532 @@
+package phylum
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyFileVersion is the policy file format version written and understood by this package
+const PolicyFileVersion = 1
+
+// policyDateLayout is the format of suppression expiry dates
+const policyDateLayout = "2006-01-02"
+
+// PolicyFile is a policy checked into a repository, written as YAML or JSON:
+//
+//	version: 1
+//	thresholds:
+//	  total: {threshold: 0.6, action: break}
+//	  malicious_code: {threshold: 0.8, action: break}
+//	rules:
+//	  min_package_score: 0.4
+//	  forbidden_risk_levels: {vulnerability: critical}
+//	  max_abandonware: 0
+//	packages:
+//	  deny:
+//	    - {name: event-stream, type: npm, versions: ">=3.3.6 <4.0.0", reason: compromised}
+//	licenses:
+//	  deny: [AGPL-3.0-only]
+//	suppressions:
+//	  - {tag: HV00001, package: lodash, reason: not reachable, expires: 2024-06-30}
+type PolicyFile struct {
+	Version      int                        `yaml:"version" json:"version"`
+	Thresholds   map[string]PolicyThreshold `yaml:"thresholds,omitempty" json:"thresholds,omitempty"` // Keyed by "total" or a RiskDomain
+	Rules        PolicyFileRules            `yaml:"rules,omitempty" json:"rules,omitempty"`
+	Packages     PolicyFilePackages         `yaml:"packages,omitempty" json:"packages,omitempty"`
+	Licenses     PolicyFileLicenses         `yaml:"licenses,omitempty" json:"licenses,omitempty"`
+	Suppressions []PolicyFileSuppression    `yaml:"suppressions,omitempty" json:"suppressions,omitempty"`
+}
+
+// PolicyThreshold is a score threshold for one risk domain. Thresholds are active unless Active is false.
+type PolicyThreshold struct {
+	Threshold float64                  `yaml:"threshold" json:"threshold"`
+	Action    ThresholdViolationAction `yaml:"action,omitempty" json:"action,omitempty"`
+	Active    *bool                    `yaml:"active,omitempty" json:"active,omitempty"`
+}
+
+// PolicyFileRules holds the local rules evaluated by EvaluatePolicy
+type PolicyFileRules struct {
+	MinPackageScore          float64                  `yaml:"min_package_score,omitempty" json:"min_package_score,omitempty"`
+	MinPackageScoreAction    ThresholdViolationAction `yaml:"min_package_score_action,omitempty" json:"min_package_score_action,omitempty"`
+	ForbiddenRiskLevels      map[RiskDomain]RiskLevel `yaml:"forbidden_risk_levels,omitempty" json:"forbidden_risk_levels,omitempty"`
+	ForbiddenRiskLevelAction ThresholdViolationAction `yaml:"forbidden_risk_level_action,omitempty" json:"forbidden_risk_level_action,omitempty"`
+	MaxAbandonware           *int                     `yaml:"max_abandonware,omitempty" json:"max_abandonware,omitempty"`
+	AbandonwareAction        ThresholdViolationAction `yaml:"abandonware_action,omitempty" json:"abandonware_action,omitempty"`
+	DenyAction               ThresholdViolationAction `yaml:"deny_action,omitempty" json:"deny_action,omitempty"`
+}
+
+// PolicyFilePackages lists packages exempt from the policy and packages that must not be used
+type PolicyFilePackages struct {
+	Allow []PolicyFilePackage `yaml:"allow,omitempty" json:"allow,omitempty"`
+	Deny  []PolicyFilePackage `yaml:"deny,omitempty" json:"deny,omitempty"`
+}
+
+// PolicyFilePackage matches packages by name, ecosystem and version range
+type PolicyFilePackage struct {
+	Name     string      `yaml:"name" json:"name"`
+	Type     PackageType `yaml:"type,omitempty" json:"type,omitempty"`
+	Versions string      `yaml:"versions,omitempty" json:"versions,omitempty"`
+	Reason   string      `yaml:"reason,omitempty" json:"reason,omitempty"`
+}
+
+// PolicyFileLicenses lists allowed and denied SPDX license identifiers
+type PolicyFileLicenses struct {
+	Allow []string `yaml:"allow,omitempty" json:"allow,omitempty"`
+	Deny  []string `yaml:"deny,omitempty" json:"deny,omitempty"`
+}
+
+// PolicyFileSuppression silences an issue by tag or ID, optionally for one package and until a date
+type PolicyFileSuppression struct {
+	Tag     string        `yaml:"tag,omitempty" json:"tag,omitempty"`
+	Id      string        `yaml:"id,omitempty" json:"id,omitempty"`
+	Package string        `yaml:"package,omitempty" json:"package,omitempty"`
+	Reason  string        `yaml:"reason" json:"reason"`
+	Kind    IgnoredReason `yaml:"kind,omitempty" json:"kind,omitempty"`       // falsePositive, notRelevant or other
+	Expires string        `yaml:"expires,omitempty" json:"expires,omitempty"` // YYYY-MM-DD, the suppression ends after this day
+}
+
+// PolicyError is a problem found at a position in a policy file
+type PolicyError struct {
+	Line   int
+	Column int
+	Field  string // Dotted path of the offending field, such as packages.deny[0].versions
+	Msg    string
+}
+
+func (e PolicyError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Field, e.Msg)
+}
+
+// PolicyValidationError lists every problem found while loading a policy file
+type PolicyValidationError struct {
+	File   string
+	Errors []PolicyError
+}
+
+func (e *PolicyValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	prefix := "invalid policy"
+	if e.File != "" {
+		prefix = fmt.Sprintf("invalid policy %s", e.File)
+	}
+	return fmt.Sprintf("%s: %s", prefix, strings.Join(messages, "; "))
+}
+
+// LoadPolicyFile reads and validates a YAML or JSON policy file
+func LoadPolicyFile(policyPath string) (*PolicyFile, error) {
+	data, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, fmt.Errorf("LoadPolicyFile(): %w", err)
+	}
+
+	policy, err := ParsePolicyFile(data)
+	var validationErr *PolicyValidationError
+	if errors.As(err, &validationErr) {
+		validationErr.File = policyPath
+	}
+	return policy, err
+}
+
+// ParsePolicyFile parses and validates the contents of a YAML or JSON policy file. Problems are
+// reported together in a *PolicyValidationError with the line of each offending field.
+func ParsePolicyFile(data []byte) (*PolicyFile, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, &PolicyValidationError{Errors: []PolicyError{yamlPolicyError(err.Error())}}
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, &PolicyValidationError{Errors: []PolicyError{{Line: 1, Column: 1, Msg: "policy must be a mapping"}}}
+	}
+	root := doc.Content[0]
+
+	v := &policyValidator{positions: map[string]*yaml.Node{}}
+	v.index(root, "", reflect.TypeOf(PolicyFile{}))
+
+	var policy PolicyFile
+	if err := root.Decode(&policy); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, &PolicyValidationError{Errors: []PolicyError{yamlPolicyError(err.Error())}}
+		}
+		for _, msg := range typeErr.Errors {
+			v.errors = append(v.errors, yamlPolicyError(msg))
+		}
+	}
+
+	if len(v.errors) == 0 {
+		v.validate(&policy)
+	}
+	if len(v.errors) > 0 {
+		return nil, &PolicyValidationError{Errors: v.errors}
+	}
+	return &policy, nil
+}
+
+// WritePolicyFile writes a policy as JSON when the path ends in .json and as YAML otherwise
+func WritePolicyFile(policyPath string, policy *PolicyFile) error {
+	var data []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(policyPath), ".json") {
+		data, err = json.MarshalIndent(policy, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(policy)
+	}
+	if err != nil {
+		return fmt.Errorf("WritePolicyFile(): %w", err)
+	}
+	return os.WriteFile(policyPath, data, 0o644)
+}
+
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlPolicyError converts a "line N: msg" error from the yaml package into a PolicyError
+func yamlPolicyError(msg string) PolicyError {
+	if match := yamlLinePattern.FindStringSubmatch(msg); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return PolicyError{Line: line, Msg: match[2]}
+	}
+	return PolicyError{Msg: strings.TrimPrefix(msg, "yaml: ")}
+}
+
+type policyValidator struct {
+	positions map[string]*yaml.Node
+	errors    []PolicyError
+}
+
+// index records the node of every field by path and reports keys that aren't part of the format
+func (v *policyValidator) index(node *yaml.Node, path string, t reflect.Type) {
+	v.positions[path] = node
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := yamlField(t, key.Value)
+			if !ok {
+				v.errors = append(v.errors, PolicyError{Line: key.Line, Column: key.Column, Field: joinPolicyPath(path, key.Value), Msg: "unknown field"})
+				continue
+			}
+			v.index(value, joinPolicyPath(path, key.Value), field.Type)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.positions[joinPolicyPath(path, node.Content[i].Value)+"#key"] = node.Content[i]
+			v.index(node.Content[i+1], joinPolicyPath(path, node.Content[i].Value), t.Elem())
+		}
+	case node.Kind == yaml.SequenceNode && t.Kind() == reflect.Slice:
+		for i, item := range node.Content {
+			v.index(item, fmt.Sprintf("%s[%d]", path, i), t.Elem())
+		}
+	}
+}
+
+// yamlField finds the struct field with the given yaml key
+func yamlField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name := strings.Split(field.Tag.Get("yaml"), ",")[0]; name == key {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinPolicyPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// fail records an error at the node for path, falling back to its closest indexed parent
+func (v *policyValidator) fail(path string, format string, args ...interface{}) {
+	err := PolicyError{Field: path, Msg: fmt.Sprintf(format, args...)}
+	for p := path; ; {
+		if node, ok := v.positions[p]; ok {
+			err.Line, err.Column = node.Line, node.Column
+			break
+		}
+		idx := strings.LastIndexAny(p, ".[")
+		if idx < 0 {
+			if node, ok := v.positions[""]; ok {
+				err.Line, err.Column = node.Line, node.Column
+			}
+			break
+		}
+		p = p[:idx]
+	}
+	v.errors = append(v.errors, err)
+}
+
+func (v *policyValidator) validate(policy *PolicyFile) {
+	if policy.Version != PolicyFileVersion {
+		if _, ok := v.positions["version"]; !ok {
+			v.fail("version", "missing, expected %d", PolicyFileVersion)
+		} else {
+			v.fail("version", "unsupported version %d, expected %d", policy.Version, PolicyFileVersion)
+		}
+	}
+
+	for _, key := range sortedKeys(policy.Thresholds) {
+		path := "thresholds." + key
+		if key != "total" && !isRiskDomain(key) {
+			v.fail(path+"#key", "unknown risk domain %q", key)
+		}
+		threshold := policy.Thresholds[key]
+		if threshold.Threshold < 0 || threshold.Threshold > 1 {
+			v.fail(path+".threshold", "threshold %v must be between 0 and 1", threshold.Threshold)
+		}
+		v.validateAction(path+".action", threshold.Action)
+	}
+
+	rules := policy.Rules
+	if rules.MinPackageScore < 0 || rules.MinPackageScore > 1 {
+		v.fail("rules.min_package_score", "score %v must be between 0 and 1", rules.MinPackageScore)
+	}
+	if rules.MaxAbandonware != nil && *rules.MaxAbandonware < 0 {
+		v.fail("rules.max_abandonware", "must not be negative")
+	}
+	for _, domain := range sortedKeys(rules.ForbiddenRiskLevels) {
+		path := "rules.forbidden_risk_levels." + string(domain)
+		if !isRiskDomain(string(domain)) {
+			v.fail(path+"#key", "unknown risk domain %q", domain)
+		}
+		if RiskLevelSeverity(rules.ForbiddenRiskLevels[domain]) < 0 {
+			v.fail(path, "unknown risk level %q", rules.ForbiddenRiskLevels[domain])
+		}
+	}
+	v.validateAction("rules.min_package_score_action", rules.MinPackageScoreAction)
+	v.validateAction("rules.forbidden_risk_level_action", rules.ForbiddenRiskLevelAction)
+	v.validateAction("rules.abandonware_action", rules.AbandonwareAction)
+	v.validateAction("rules.deny_action", rules.DenyAction)
+
+	packageLists := []struct {
+		path     string
+		packages []PolicyFilePackage
+	}{{"packages.allow", policy.Packages.Allow}, {"packages.deny", policy.Packages.Deny}}
+	for _, list := range packageLists {
+		for i, pkg := range list.packages {
+			path := fmt.Sprintf("%s[%d]", list.path, i)
+			if strings.TrimSpace(pkg.Name) == "" {
+				v.fail(path+".name", "package name is required")
+			}
+			switch pkg.Type {
+			case "", Npm, Pypi, Maven, Nuget, Rubygems:
+			default:
+				v.fail(path+".type", "unknown package type %q", pkg.Type)
+			}
+			if _, err := ParseVersionRange(pkg.Versions); err != nil {
+				v.fail(path+".versions", "%v", err)
+			}
+		}
+	}
+
+	licenseLists := []struct {
+		path     string
+		licenses []string
+	}{{"licenses.allow", policy.Licenses.Allow}, {"licenses.deny", policy.Licenses.Deny}}
+	for _, list := range licenseLists {
+		for i, license := range list.licenses {
+			switch {
+			case strings.TrimSpace(license) == "":
+				v.fail(fmt.Sprintf("%s[%d]", list.path, i), "license identifier is required")
+			case !isLicenseIdentifier(canonicalLicenseID(license)):
+				v.fail(fmt.Sprintf("%s[%d]", list.path, i), "invalid license identifier %q", license)
+			}
+		}
+	}
+
+	for i, suppression := range policy.Suppressions {
+		path := fmt.Sprintf("suppressions[%d]", i)
+		if suppression.Tag == "" && suppression.Id == "" {
+			v.fail(path, "one of tag or id is required")
+		}
+		if strings.TrimSpace(suppression.Reason) == "" {
+			v.fail(path+".reason", "a reason is required")
+		}
+		switch suppression.Kind {
+		case "", False, FalsePositive, NotRelevant, Other:
+		default:
+			v.fail(path+".kind", "unknown kind %q", suppression.Kind)
+		}
+		if suppression.Expires != "" {
+			if _, err := time.Parse(policyDateLayout, suppression.Expires); err != nil {
+				v.fail(path+".expires", "expected a date in YYYY-MM-DD format, got %q", suppression.Expires)
+			}
+		}
+	}
+
+	// Report problems top to bottom rather than in validation order
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		if v.errors[i].Line != v.errors[j].Line {
+			return v.errors[i].Line < v.errors[j].Line
+		}
+		return v.errors[i].Column < v.errors[j].Column
+	})
+}
+
+func (v *policyValidator) validateAction(path string, action ThresholdViolationAction) {
+	switch action {
+	case "", ThresholdViolationActionBreak, ThresholdViolationActionWarn, ThresholdViolationActionNone:
+	default:
+		v.fail(path, "unknown action %q, expected break, warn or none", action)
+	}
+}
+
+// isRiskDomain reports whether name is one of the RiskDomain values
+func isRiskDomain(name string) bool {
+	switch RiskDomain(name) {
+	case RiskDomainAuthor, RiskDomainEngineering, RiskDomainLicense, RiskDomainMaliciousCode, RiskDomainVulnerability:
+		return true
+	}
+	return false
+}
+
+// RiskThresholds returns the policy thresholds. Domains without a threshold are inactive and
+// thresholds without an action break the build.
+func (f *PolicyFile) RiskThresholds() RiskThresholds {
+	descriptor := func(key string) ThresholdDescriptor {
+		threshold, ok := f.Thresholds[key]
+		if !ok {
+			return ThresholdDescriptor{Action: ThresholdViolationActionNone}
+		}
+		return ThresholdDescriptor{
+			Action:    defaultAction(threshold.Action),
+			Active:    threshold.Active == nil || *threshold.Active,
+			Threshold: float32(threshold.Threshold),
+		}
+	}
+	return RiskThresholds{
+		Author:        descriptor(string(RiskDomainAuthor)),
+		Engineering:   descriptor(string(RiskDomainEngineering)),
+		License:       descriptor(string(RiskDomainLicense)),
+		MaliciousCode: descriptor(string(RiskDomainMaliciousCode)),
+		Total:         descriptor("total"),
+		Vulnerability: descriptor(string(RiskDomainVulnerability)),
+	}
+}
+
+// PolicyRules returns the rules of the policy for use with EvaluatePolicy
+func (f *PolicyFile) PolicyRules() *PolicyRules {
+	rules := &PolicyRules{
+		DenyAction:               f.Rules.DenyAction,
+		MinPackageScore:          f.Rules.MinPackageScore,
+		MinPackageScoreAction:    f.Rules.MinPackageScoreAction,
+		ForbiddenRiskLevels:      f.Rules.ForbiddenRiskLevels,
+		ForbiddenRiskLevelAction: f.Rules.ForbiddenRiskLevelAction,
+		MaxAbandonware:           f.Rules.MaxAbandonware,
+		AbandonwareAction:        f.Rules.AbandonwareAction,
+	}
+	for _, pkg := range f.Packages.Allow {
+		rules.AllowPackages = append(rules.AllowPackages, pkg.packageRule())
+	}
+	for _, pkg := range f.Packages.Deny {
+		rules.DenyPackages = append(rules.DenyPackages, pkg.packageRule())
+	}
+	for _, suppression := range f.Suppressions {
+		rules.Suppressions = append(rules.Suppressions, suppression.issueSuppression())
+	}
+	return rules
+}
+
+func (p PolicyFilePackage) packageRule() PackageRule {
+	// Ranges are validated when the file is loaded
+	versions, _ := ParseVersionRange(p.Versions)
+	return PackageRule{Name: p.Name, Type: p.Type, Versions: versions, Reason: p.Reason}
+}
+
+func (s PolicyFileSuppression) issueSuppression() IssueSuppression {
+	suppression := IssueSuppression{Tag: s.Tag, Id: s.Id, Package: s.Package, Reason: s.Reason, Kind: s.Kind}
+	if expires, err := time.Parse(policyDateLayout, s.Expires); err == nil {
+		// The suppression lasts through the whole expiry day
+		suppression.Expires = expires.Add(24*time.Hour - time.Nanosecond)
+	}
+	return suppression
+}
+
+// ProjectPreferences converts the policy to Phylum project preferences so it can be synced to the
+// server. Thresholds and unexpired suppressions that aren't limited to one package are carried over;
+// the remaining rules only exist locally.
+func (f *PolicyFile) ProjectPreferences(now time.Time) ProjectPreferences {
+	var prefs ProjectPreferences
+	prefs.Thresholds = f.RiskThresholds()
+
+	ignored := []IgnoredIssue{}
+	for _, s := range f.Suppressions {
+		suppression := s.issueSuppression()
+		if s.Package != "" || (!suppression.Expires.IsZero() && now.After(suppression.Expires)) {
+			continue
+		}
+		kind := s.Kind
+		if kind == "" {
+			kind = Other
+		}
+		ignored = append(ignored, IgnoredIssue{Id: s.Id, Tag: s.Tag, Reason: kind})
+	}
+	prefs.IgnoredIssues = &ignored
+	return prefs
+}
+
+// PolicyFileFromProjectPreferences builds a policy file from Phylum project preferences, for
+// checking the server configuration into a repository
+func PolicyFileFromProjectPreferences(prefs *ProjectPreferences) *PolicyFile {
+	policy := &PolicyFile{Version: PolicyFileVersion, Thresholds: map[string]PolicyThreshold{}}
+	if prefs == nil {
+		return policy
+	}
+
+	thresholds := map[string]ThresholdDescriptor{
+		"total":                         prefs.Thresholds.Total,
+		string(RiskDomainAuthor):        prefs.Thresholds.Author,
+		string(RiskDomainEngineering):   prefs.Thresholds.Engineering,
+		string(RiskDomainLicense):       prefs.Thresholds.License,
+		string(RiskDomainMaliciousCode): prefs.Thresholds.MaliciousCode,
+		string(RiskDomainVulnerability): prefs.Thresholds.Vulnerability,
+	}
+	for key, descriptor := range thresholds {
+		if !descriptor.Active && descriptor.Threshold == 0 {
+			continue
+		}
+		// Format through float32 so 0.6 isn't written as 0.6000000238418579
+		value, _ := strconv.ParseFloat(strconv.FormatFloat(float64(descriptor.Threshold), 'g', -1, 32), 64)
+		threshold := PolicyThreshold{Threshold: value, Action: descriptor.Action}
+		if !descriptor.Active {
+			inactive := false
+			threshold.Active = &inactive
+		}
+		policy.Thresholds[key] = threshold
+	}
+
+	if prefs.IgnoredIssues != nil {
+		for _, issue := range *prefs.IgnoredIssues {
+			policy.Suppressions = append(policy.Suppressions, PolicyFileSuppression{
+				Tag:    issue.Tag,
+				Id:     issue.Id,
+				Reason: "imported from project preferences",
+				Kind:   issue.Reason,
+			})
+		}
+	}
+	return policy
+}
@@ -0,0 +1,210 @@
+package phylum
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testPolicyYAML = `version: 1
+thresholds:
+  total: {threshold: 0.6, action: break}
+  vulnerability:
+    threshold: 0.7
+    action: warn
+  author: {threshold: 0.5, action: break, active: false}
+rules:
+  min_package_score: 0.3
+  forbidden_risk_levels:
+    malicious_code: high
+  max_abandonware: 2
+packages:
+  allow:
+    - {name: internal-lib, type: npm}
+  deny:
+    - name: event-stream
+      type: npm
+      versions: ">=3.3.6 <4.0.0"
+      reason: compromised release
+licenses:
+  allow: [MIT, Apache-2.0]
+  deny: [AGPL-3.0-only]
+suppressions:
+  - tag: HV00001
+    reason: not reachable from our code
+    kind: notRelevant
+    expires: 2030-01-31
+  - {id: abc-123, package: lodash, reason: patched in our fork}
+`
+
+const testPolicyJSON = `{
+	"version": 1,
+	"thresholds": {
+		"total": {"threshold": 0.6, "action": "break"},
+		"vulnerability": {"threshold": 0.7, "action": "warn"},
+		"author": {"threshold": 0.5, "action": "break", "active": false}
+	},
+	"rules": {"min_package_score": 0.3, "forbidden_risk_levels": {"malicious_code": "high"}, "max_abandonware": 2},
+	"packages": {
+		"allow": [{"name": "internal-lib", "type": "npm"}],
+		"deny": [{"name": "event-stream", "type": "npm", "versions": ">=3.3.6 <4.0.0", "reason": "compromised release"}]
+	},
+	"licenses": {"allow": ["MIT", "Apache-2.0"], "deny": ["AGPL-3.0-only"]},
+	"suppressions": [
+		{"tag": "HV00001", "reason": "not reachable from our code", "kind": "notRelevant", "expires": "2030-01-31"},
+		{"id": "abc-123", "package": "lodash", "reason": "patched in our fork"}
+	]
+}`
+
+func TestParsePolicyFile(t *testing.T) {
+	fromYAML, err := ParsePolicyFile([]byte(testPolicyYAML))
+	if err != nil {
+		t.Fatalf("ParsePolicyFile() yaml error = %v", err)
+	}
+	fromJSON, err := ParsePolicyFile([]byte(testPolicyJSON))
+	if err != nil {
+		t.Fatalf("ParsePolicyFile() json error = %v", err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Errorf("ParsePolicyFile(): yaml and json policies differ:\n%+v\n%+v", fromYAML, fromJSON)
+	}
+
+	thresholds := fromYAML.RiskThresholds()
+	wantTotal := ThresholdDescriptor{Action: ThresholdViolationActionBreak, Active: true, Threshold: 0.6}
+	if thresholds.Total != wantTotal {
+		t.Errorf("RiskThresholds() total got = %+v, want %+v", thresholds.Total, wantTotal)
+	}
+	if thresholds.Author.Active || thresholds.Engineering.Active {
+		t.Errorf("RiskThresholds(): author and engineering should be inactive")
+	}
+
+	rules := fromYAML.PolicyRules()
+	tests := []struct {
+		pkg  PackageDescriptor
+		want bool
+	}{
+		{PackageDescriptor{Name: "event-stream", Version: "3.3.6", Type: Npm}, true},
+		{PackageDescriptor{Name: "event-stream", Version: "3.3.5", Type: Npm}, false},
+		{PackageDescriptor{Name: "event-stream", Version: "4.0.0", Type: Npm}, false},
+		{PackageDescriptor{Name: "event-stream", Version: "3.3.6", Type: Pypi}, false},
+	}
+	for _, tt := range tests {
+		if got := rules.DenyPackages[0].Matches(tt.pkg); got != tt.want {
+			t.Errorf("PolicyRules(): deny rule Matches(%v) got = %v, want %v", tt.pkg, got, tt.want)
+		}
+	}
+	if got := rules.Suppressions[0].Expires; got.Format(time.RFC3339) != "2030-01-31T23:59:59Z" {
+		t.Errorf("PolicyRules(): suppression expiry got = %v", got)
+	}
+}
+
+func TestParsePolicyFile_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []PolicyError
+	}{
+		{
+			"syntax",
+			"version: 1\nthresholds: [\n",
+			[]PolicyError{{Line: 2, Msg: "did not find expected node content"}},
+		},
+		{
+			"missing version",
+			"rules:\n  min_package_score: 0.5\n",
+			[]PolicyError{{Line: 1, Column: 1, Field: "version", Msg: "missing, expected 1"}},
+		},
+		{
+			"type mismatch",
+			"version: 1\nrules:\n  min_package_score: high\n",
+			[]PolicyError{{Line: 3, Msg: "cannot unmarshal !!str `high` into float64"}},
+		},
+		{
+			"unknown fields",
+			"version: 1\nrule:\n  min: 1\npackages:\n  deny:\n    - {name: a, version: 1}\n",
+			[]PolicyError{
+				{Line: 2, Column: 1, Field: "rule", Msg: "unknown field"},
+				{Line: 6, Column: 17, Field: "packages.deny[0].version", Msg: "unknown field"},
+			},
+		},
+		{
+			"semantic",
+			`version: 2
+thresholds:
+  malicious: {threshold: 1.5, action: stop}
+rules:
+  forbidden_risk_levels: {vulnerability: severe}
+packages:
+  deny:
+    - name: ""
+      type: cargo
+      versions: "~>1.0"
+suppressions:
+  - reason: ""
+    expires: next week
+`,
+			[]PolicyError{
+				{Line: 1, Column: 10, Field: "version", Msg: "unsupported version 2, expected 1"},
+				{Line: 3, Column: 3, Field: "thresholds.malicious#key", Msg: `unknown risk domain "malicious"`},
+				{Line: 3, Column: 26, Field: "thresholds.malicious.threshold", Msg: "threshold 1.5 must be between 0 and 1"},
+				{Line: 3, Column: 39, Field: "thresholds.malicious.action", Msg: `unknown action "stop", expected break, warn or none`},
+				{Line: 5, Column: 42, Field: "rules.forbidden_risk_levels.vulnerability", Msg: `unknown risk level "severe"`},
+				{Line: 8, Column: 13, Field: "packages.deny[0].name", Msg: "package name is required"},
+				{Line: 9, Column: 13, Field: "packages.deny[0].type", Msg: `unknown package type "cargo"`},
+				{Line: 10, Column: 17, Field: "packages.deny[0].versions", Msg: `invalid version "~>1.0" in version range "~>1.0"`},
+				{Line: 12, Column: 5, Field: "suppressions[0]", Msg: "one of tag or id is required"},
+				{Line: 12, Column: 13, Field: "suppressions[0].reason", Msg: "a reason is required"},
+				{Line: 13, Column: 14, Field: "suppressions[0].expires", Msg: `expected a date in YYYY-MM-DD format, got "next week"`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePolicyFile([]byte(tt.data))
+			var validationErr *PolicyValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("ParsePolicyFile() error = %v, want *PolicyValidationError", err)
+			}
+			if !reflect.DeepEqual(validationErr.Errors, tt.want) {
+				t.Errorf("ParsePolicyFile() errors got:\n%v\nwant:\n%v", validationErr.Errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyFile_ProjectPreferences(t *testing.T) {
+	policy, err := ParsePolicyFile([]byte(testPolicyYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prefs := policy.ProjectPreferences(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	wantIgnored := []IgnoredIssue{{Tag: "HV00001", Reason: NotRelevant}}
+	if !reflect.DeepEqual(*prefs.IgnoredIssues, wantIgnored) {
+		t.Errorf("ProjectPreferences() ignored issues got = %v, want %v", *prefs.IgnoredIssues, wantIgnored)
+	}
+	if expired := policy.ProjectPreferences(time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)); len(*expired.IgnoredIssues) != 0 {
+		t.Errorf("ProjectPreferences(): expired suppressions should not be synced")
+	}
+
+	roundTrip := PolicyFileFromProjectPreferences(&prefs)
+	if !reflect.DeepEqual(roundTrip.Thresholds, policy.Thresholds) {
+		t.Errorf("PolicyFileFromProjectPreferences() thresholds got = %v, want %v", roundTrip.Thresholds, policy.Thresholds)
+	}
+
+	for _, name := range []string{"policy.yml", "policy.json"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := WritePolicyFile(path, roundTrip); err != nil {
+			t.Fatalf("WritePolicyFile() error = %v", err)
+		}
+		loaded, err := LoadPolicyFile(path)
+		if err != nil {
+			t.Fatalf("LoadPolicyFile(%v) error = %v", name, err)
+		}
+		if !reflect.DeepEqual(loaded, roundTrip) {
+			t.Errorf("LoadPolicyFile(%v) got = %+v, want %+v", name, loaded, roundTrip)
+		}
+	}
+}
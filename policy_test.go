@@ -0,0 +1,146 @@
+package phylum
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEvaluatePolicy(t *testing.T) {
+	score := func(v float64) *float64 { return &v }
+	abandonedTag := "abandoned"
+	job := &JobStatusResponseForPackageStatusExtended{
+		Packages: []PackageStatusExtended{
+			{
+				Name: "left-pad", Version: "1.3.0", Type: "npm", PackageScore: score(0.9),
+				Issues: []Issue{{Title: "Package is unmaintained", Tag: &abandonedTag, Domain: RiskDomainAuthor, Severity: Low}},
+			},
+			{
+				Name: "event-stream", Version: "3.3.6", Type: "npm", PackageScore: score(0.2),
+				RiskVectors: PackageStatusExtended_RiskVectors{AdditionalProperties: map[string]float64{"malicious_code": 0.1, "author": 0.9}},
+				Issues:      []Issue{{Title: "Malicious code", Domain: RiskDomainMaliciousCode, Severity: Critical}},
+			},
+			{
+				Name: "Django", Version: "2.2.0", Type: "pypi", PackageScore: score(0.6),
+				RiskVectors: PackageStatusExtended_RiskVectors{AdditionalProperties: map[string]float64{"vulnerability": 0.5}},
+				Issues:      []Issue{{Title: "SQL injection", Domain: RiskDomainVulnerability, Severity: Medium}},
+			},
+		},
+	}
+	thresholds := &RiskThresholds{
+		Total:         ThresholdDescriptor{Active: true, Threshold: 0.5, Action: ThresholdViolationActionBreak},
+		MaliciousCode: ThresholdDescriptor{Active: true, Threshold: 0.6, Action: ThresholdViolationActionBreak},
+		Vulnerability: ThresholdDescriptor{Active: true, Threshold: 0.7, Action: ThresholdViolationActionWarn},
+		Author:        ThresholdDescriptor{Active: false, Threshold: 1, Action: ThresholdViolationActionBreak},
+	}
+	zero := 0
+
+	type args struct {
+		thresholds *RiskThresholds
+		rules      *PolicyRules
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantAction ThresholdViolationAction
+		wantRules  []string
+	}{
+		{"no policy", args{nil, nil}, ThresholdViolationActionNone, nil},
+		{"thresholds only", args{thresholds, nil}, ThresholdViolationActionBreak, []string{PolicyRuleThreshold, PolicyRuleThreshold, PolicyRuleThreshold}},
+		{
+			"warn only rules",
+			args{nil, &PolicyRules{
+				DenyPackages: []PackageRule{{Name: "django", Type: Pypi, Reason: "use the internal fork"}},
+				DenyAction:   ThresholdViolationActionWarn,
+			}},
+			ThresholdViolationActionWarn,
+			[]string{PolicyRuleDeny},
+		},
+		{
+			"custom rules",
+			args{nil, &PolicyRules{
+				DenyPackages:        []PackageRule{{Name: "left-pad", Version: "1.0.0"}},
+				MinPackageScore:     0.5,
+				ForbiddenRiskLevels: map[RiskDomain]RiskLevel{RiskDomainMaliciousCode: High, RiskDomainVulnerability: High},
+				MaxAbandonware:      &zero,
+				AbandonwareAction:   ThresholdViolationActionWarn,
+			}},
+			ThresholdViolationActionBreak,
+			[]string{PolicyRuleMinScore, PolicyRuleRiskLevel, PolicyRuleAbandonware},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EvaluatePolicy(job, tt.args.thresholds, tt.args.rules)
+			if got.Action != tt.wantAction {
+				t.Errorf("EvaluatePolicy() action got = %v, want %v", got.Action, tt.wantAction)
+			}
+			var gotRules []string
+			for _, violation := range got.Violations {
+				gotRules = append(gotRules, violation.Rule)
+			}
+			if !reflect.DeepEqual(gotRules, tt.wantRules) {
+				t.Errorf("EvaluatePolicy() rules got = %v, want %v", gotRules, tt.wantRules)
+			}
+			if got.Pass() != (tt.wantAction != ThresholdViolationActionBreak) {
+				t.Errorf("Pass() got = %v", got.Pass())
+			}
+		})
+	}
+
+	verdict := EvaluatePolicy(job, thresholds, nil)
+	warnings := verdict.ViolationsWithAction(ThresholdViolationActionWarn)
+	if len(warnings) != 1 || warnings[0].Package.Name != "Django" || warnings[0].Domain != RiskDomainVulnerability {
+		t.Errorf("ViolationsWithAction(warn) got = %+v", warnings)
+	}
+}
+
+func TestEvaluatePolicy_AllowAndSuppress(t *testing.T) {
+	tag := "HM00001"
+	job := &JobStatusResponseForPackageStatusExtended{
+		Packages: []PackageStatusExtended{
+			{Name: "internal-lib", Version: "1.0.0", Type: "npm", Issues: []Issue{{Title: "Obfuscated code", Domain: RiskDomainMaliciousCode, Severity: Critical}}},
+			{Name: "minifier", Version: "2.0.0", Type: "npm", Issues: []Issue{{Title: "Obfuscated code", Tag: &tag, Domain: RiskDomainMaliciousCode, Severity: Critical}}},
+		},
+	}
+	rules := &PolicyRules{
+		AllowPackages:       []PackageRule{{Name: "internal-lib"}},
+		ForbiddenRiskLevels: map[RiskDomain]RiskLevel{RiskDomainMaliciousCode: High},
+	}
+
+	if got := EvaluatePolicy(job, nil, rules); len(got.Violations) != 1 || got.Violations[0].Package.Name != "minifier" {
+		t.Errorf("EvaluatePolicy() with allow list got = %+v", got.Violations)
+	}
+
+	rules.Suppressions = []IssueSuppression{{Tag: tag, Package: "minifier", Reason: "bundled build output"}}
+	if got := EvaluatePolicy(job, nil, rules); !got.Pass() || len(got.Violations) != 0 {
+		t.Errorf("EvaluatePolicy() with suppression got = %+v", got.Violations)
+	}
+
+	rules.Suppressions[0].Expires = time.Now().Add(-time.Hour)
+	if got := EvaluatePolicy(job, nil, rules); got.Pass() {
+		t.Errorf("EvaluatePolicy(): expired suppression should not apply")
+	}
+}
+
+func TestIsAbandonware(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name  string
+		issue Issue
+		want  bool
+	}{
+		{"tagged", Issue{Title: "Package is unmaintained", Tag: str("abandoned"), Domain: RiskDomainAuthor}, true},
+		{"tag case", Issue{Title: "Package is unmaintained", Tag: str("Abandonware"), Domain: RiskDomainAuthor}, true},
+		{"mentions the word", Issue{Title: "Use after free in abandoned handle", Description: "Abandoned connections are not closed", Tag: str("HV00001"), Domain: RiskDomainVulnerability}, false},
+		{"tag in another domain", Issue{Title: "Abandoned dependency", Tag: str("abandoned"), Domain: RiskDomainEngineering}, false},
+		{"untagged", Issue{Title: "Package is abandoned", Domain: RiskDomainAuthor}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAbandonware(PackageStatusExtended{Issues: []Issue{tt.issue}}); got != tt.want {
+				t.Errorf("IsAbandonware() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package phylum
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// purlTypes maps Phylum package types to package URL types
+var purlTypes = map[PackageType]string{
+	Npm:      "npm",
+	Pypi:     "pypi",
+	Maven:    "maven",
+	Nuget:    "nuget",
+	Rubygems: "gem",
+}
+
+// PackageURL returns the package URL (purl) of a package, such as pkg:npm/%40babel/core@7.20.0.
+// Maven names of the form group:artifact become a namespace and name, npm scopes become the
+// namespace and PyPI names are normalized as the purl spec requires. Packages of an unknown type
+// return an empty string.
+func PackageURL(pkg PackageDescriptor) string {
+	purlType, ok := purlTypes[pkg.Type]
+	if !ok || pkg.Name == "" {
+		return ""
+	}
+
+	namespace, name := purlNamespace(pkg)
+	var b strings.Builder
+	b.WriteString("pkg:" + purlType + "/")
+	if namespace != "" {
+		for _, segment := range strings.Split(namespace, "/") {
+			b.WriteString(purlEscape(segment) + "/")
+		}
+	}
+	b.WriteString(purlEscape(name))
+	if pkg.Version != "" {
+		b.WriteString("@" + purlEscape(pkg.Version))
+	}
+	return b.String()
+}
+
+// purlNamespace splits a package name into its purl namespace and name
+func purlNamespace(pkg PackageDescriptor) (string, string) {
+	switch pkg.Type {
+	case Npm:
+		if strings.HasPrefix(pkg.Name, "@") {
+			if idx := strings.Index(pkg.Name, "/"); idx > 0 {
+				return pkg.Name[:idx], pkg.Name[idx+1:]
+			}
+		}
+	case Maven:
+		if idx := strings.Index(pkg.Name, ":"); idx > 0 {
+			return pkg.Name[:idx], pkg.Name[idx+1:]
+		}
+	case Pypi:
+		return "", normalizePythonName(pkg.Name)
+	}
+	return "", pkg.Name
+}
+
+// purlEscape percent-encodes everything but unreserved characters
+func purlEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("-._~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// ErrUnsupportedPackageURL is returned by ParsePackageURL for purl types Phylum cannot analyze
+var ErrUnsupportedPackageURL = errors.New("unsupported package URL type")
+
+// ParsePackageURL parses a package URL into a PackageDescriptor. Qualifiers and subpaths are
+// ignored. Purl types other than npm, pypi, maven, nuget and gem return an error wrapping
+// ErrUnsupportedPackageURL.
+func ParsePackageURL(purl string) (PackageDescriptor, error) {
+	rest := purl
+	if len(rest) < 4 || !strings.EqualFold(rest[:4], "pkg:") {
+		return PackageDescriptor{}, fmt.Errorf("ParsePackageURL(): %q is not a package URL", purl)
+	}
+	rest = strings.TrimLeft(rest[4:], "/")
+	if idx := strings.IndexAny(rest, "?#"); idx >= 0 {
+		rest = rest[:idx]
+	}
+
+	slash := strings.Index(rest, "/")
+	if slash <= 0 {
+		return PackageDescriptor{}, fmt.Errorf("ParsePackageURL(): %q has no name", purl)
+	}
+	purlType, path := strings.ToLower(rest[:slash]), rest[slash+1:]
+
+	var version string
+	if at := strings.LastIndex(path, "@"); at > strings.LastIndex(path, "/") {
+		path, version = path[:at], path[at+1:]
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return PackageDescriptor{}, fmt.Errorf("ParsePackageURL(): %q: %w", purl, err)
+		}
+		segments[i] = unescaped
+	}
+	unescapedVersion, err := url.PathUnescape(version)
+	if err != nil {
+		return PackageDescriptor{}, fmt.Errorf("ParsePackageURL(): %q: %w", purl, err)
+	}
+
+	name := segments[len(segments)-1]
+	namespace := strings.Join(segments[:len(segments)-1], "/")
+	pkg := PackageDescriptor{Name: name, Version: unescapedVersion}
+	switch purlType {
+	case "npm":
+		pkg.Type = Npm
+		if namespace != "" {
+			pkg.Name = namespace + "/" + name
+		}
+	case "maven":
+		pkg.Type = Maven
+		if namespace != "" {
+			pkg.Name = strings.ReplaceAll(namespace, "/", ".") + ":" + name
+		}
+	case "pypi":
+		pkg.Type = Pypi
+	case "nuget":
+		pkg.Type = Nuget
+	case "gem":
+		pkg.Type = Rubygems
+	default:
+		return PackageDescriptor{}, fmt.Errorf("ParsePackageURL(): %w %q", ErrUnsupportedPackageURL, purlType)
+	}
+	if pkg.Name == "" {
+		return PackageDescriptor{}, fmt.Errorf("ParsePackageURL(): %q has no name", purl)
+	}
+	return pkg, nil
+}
@@ -0,0 +1,67 @@
+package phylum
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPackageURL(t *testing.T) {
+	tests := []struct {
+		name string
+		pkg  PackageDescriptor
+		want string
+	}{
+		{"npm", PackageDescriptor{Name: "lodash", Version: "4.17.21", Type: Npm}, "pkg:npm/lodash@4.17.21"},
+		{"npm scoped", PackageDescriptor{Name: "@babel/core", Version: "7.20.0", Type: Npm}, "pkg:npm/%40babel/core@7.20.0"},
+		{"pypi normalized", PackageDescriptor{Name: "Flask_SQLAlchemy", Version: "3.0.0", Type: Pypi}, "pkg:pypi/flask-sqlalchemy@3.0.0"},
+		{"maven", PackageDescriptor{Name: "org.apache.commons:commons-lang3", Version: "3.12.0", Type: Maven}, "pkg:maven/org.apache.commons/commons-lang3@3.12.0"},
+		{"nuget", PackageDescriptor{Name: "Newtonsoft.Json", Version: "13.0.1", Type: Nuget}, "pkg:nuget/Newtonsoft.Json@13.0.1"},
+		{"gem with build metadata", PackageDescriptor{Name: "nokogiri", Version: "1.13.0+x86", Type: Rubygems}, "pkg:gem/nokogiri@1.13.0%2Bx86"},
+		{"no version", PackageDescriptor{Name: "rails", Type: Rubygems}, "pkg:gem/rails"},
+		{"unknown type", PackageDescriptor{Name: "serde", Version: "1.0.0", Type: "cargo"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PackageURL(tt.pkg); got != tt.want {
+				t.Errorf("PackageURL() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePackageURL(t *testing.T) {
+	tests := []struct {
+		purl    string
+		want    PackageDescriptor
+		wantErr error
+	}{
+		{"pkg:npm/%40babel/core@7.20.0", PackageDescriptor{Name: "@babel/core", Version: "7.20.0", Type: Npm}, nil},
+		{"pkg:npm/@babel/core@7.20.0", PackageDescriptor{Name: "@babel/core", Version: "7.20.0", Type: Npm}, nil},
+		{"pkg:pypi/django@4.1?extension=whl", PackageDescriptor{Name: "django", Version: "4.1", Type: Pypi}, nil},
+		{"pkg:maven/org.apache.commons/commons-lang3@3.12.0#src", PackageDescriptor{Name: "org.apache.commons:commons-lang3", Version: "3.12.0", Type: Maven}, nil},
+		{"pkg:NuGet/Newtonsoft.Json@13.0.1", PackageDescriptor{Name: "Newtonsoft.Json", Version: "13.0.1", Type: Nuget}, nil},
+		{"pkg:gem/nokogiri@1.13.0%2Bx86", PackageDescriptor{Name: "nokogiri", Version: "1.13.0+x86", Type: Rubygems}, nil},
+		{"pkg:gem/rails", PackageDescriptor{Name: "rails", Type: Rubygems}, nil},
+		{"pkg:cargo/serde@1.0.0", PackageDescriptor{}, ErrUnsupportedPackageURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.purl, func(t *testing.T) {
+			got, err := ParsePackageURL(tt.purl)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParsePackageURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePackageURL() got = %+v, want %+v", got, tt.want)
+			}
+			if err == nil && tt.want.Version != "" && PackageURL(got) != PackageURL(tt.want) {
+				t.Errorf("PackageURL() does not round trip %v", tt.purl)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"npm/lodash@1.0.0", "pkg:npm", "pkg:npm/%zz@1.0.0"} {
+		if _, err := ParsePackageURL(invalid); err == nil || errors.Is(err, ErrUnsupportedPackageURL) {
+			t.Errorf("ParsePackageURL(%q) error = %v, want invalid purl error", invalid, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package phylum
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Remediation is an issue description split into its sections
+type Remediation struct {
+	Overview         string
+	AffectedVersions string
+	Recommendation   string
+	References       []string          // URLs from the references section
+	CVEs             []string          // CVE IDs mentioned anywhere in the description
+	FixedIn          string            // First version stated to fix the issue, empty when not stated
+	Sections         map[string]string // Every section, keyed by lowercased heading
+}
+
+var (
+	markdownHeadingPattern = regexp.MustCompile(`^#{1,6}\s+(.+?)\s*#*\s*$`)
+	cvePattern             = regexp.MustCompile(`(?i)\bCVE-\d{4}-\d{4,}\b`)
+	urlPattern             = regexp.MustCompile(`https?://[^\s<>()\[\]"']+`)
+
+	// Phrasings that state the version fixing an issue, tried in order
+	fixedInPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`>=\s*v?(\d+(?:\.\d+)*(?:-[0-9A-Za-z.]+)?)`),
+		regexp.MustCompile(`(?i)\b(?:fixed|patched|resolved|addressed)\s+in\s+(?:version\s+)?v?(\d+(?:\.\d+)*(?:-[0-9A-Za-z.]+)?)`),
+		regexp.MustCompile(`(?i)\b(?:upgrade|update|bump)\b[^.\n]*?\bto\s+(?:version\s+)?v?(\d+(?:\.\d+)*(?:-[0-9A-Za-z.]+)?)`),
+		regexp.MustCompile(`(?i)\bversion\s+v?(\d+(?:\.\d+)*(?:-[0-9A-Za-z.]+)?)\s+(?:or|and)\s+(?:later|higher|newer|above)`),
+		regexp.MustCompile(`(?i)\bpatched\s+versions?:?\s+v?(\d+(?:\.\d+)*(?:-[0-9A-Za-z.]+)?)`),
+	}
+	// An exclusive upper bound of the affected range, as in ">= 1.0.0, < 1.2.3"
+	affectedUpperBoundPattern = regexp.MustCompile(`(?:^|[\s,(])<\s*v?(\d+(?:\.\d+)*(?:-[0-9A-Za-z.]+)?)`)
+)
+
+// ParseRemediation splits issue description markdown into sections by heading. Text before the
+// first heading is the overview. Unlike ExtractRemediation, the recommendation stops at the next
+// heading.
+func ParseRemediation(description string) *Remediation {
+	r := &Remediation{Sections: make(map[string]string)}
+
+	var heading string
+	var body []string
+	var order []string
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(body, "\n"))
+		body = nil
+		if heading == "" && text == "" {
+			return
+		}
+		if _, ok := r.Sections[heading]; !ok {
+			order = append(order, heading)
+		}
+		r.Sections[heading] = joinSection(r.Sections[heading], text)
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(description, "\r\n", "\n"), "\n") {
+		if m := markdownHeadingPattern.FindStringSubmatch(line); m != nil {
+			flush()
+			heading = strings.ToLower(strings.TrimSuffix(m[1], ":"))
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+
+	for _, heading := range order {
+		text := r.Sections[heading]
+		switch remediationSection(heading) {
+		case "overview":
+			r.Overview = joinSection(r.Overview, text)
+		case "affected":
+			r.AffectedVersions = joinSection(r.AffectedVersions, text)
+		case "recommendation":
+			r.Recommendation = joinSection(r.Recommendation, text)
+		case "references":
+			r.References = append(r.References, urlPattern.FindAllString(text, -1)...)
+		}
+	}
+	r.References = dedupeStrings(r.References)
+
+	for _, cve := range cvePattern.FindAllString(description, -1) {
+		r.CVEs = append(r.CVEs, strings.ToUpper(cve))
+	}
+	r.CVEs = dedupeStrings(r.CVEs)
+
+	r.FixedIn = fixedInVersion(r.Recommendation)
+	if r.FixedIn == "" {
+		// Affected ranges state where the vulnerability starts, so only their upper bound is used
+		if m := affectedUpperBoundPattern.FindStringSubmatch(r.AffectedVersions); m != nil {
+			r.FixedIn = strings.TrimRight(m[1], ".-")
+		}
+	}
+	return r
+}
+
+// remediationSection maps a heading to the section of a Remediation it fills
+func remediationSection(heading string) string {
+	switch heading {
+	case "", "overview", "description", "summary", "details", "impact":
+		return "overview"
+	case "affected versions", "affected version", "vulnerable versions", "affected":
+		return "affected"
+	case "recommendation", "recommendations", "remediation", "mitigation", "fix", "solution":
+		return "recommendation"
+	case "references", "reference", "links", "more information":
+		return "references"
+	}
+	return ""
+}
+
+func joinSection(existing string, text string) string {
+	if existing == "" || text == "" {
+		return existing + text
+	}
+	return existing + "\n\n" + text
+}
+
+func fixedInVersion(text string) string {
+	for _, pattern := range fixedInPatterns {
+		if m := pattern.FindStringSubmatch(text); m != nil {
+			return strings.TrimRight(m[1], ".-")
+		}
+	}
+	return ""
+}
+
+func dedupeStrings(values []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// Suggestion returns an upgrade suggestion such as "upgrade qs to >= 6.11.1", or an empty string
+// when the fixed version is unknown or pkg is already at or above it in its ecosystem
+func (r *Remediation) Suggestion(pkg PackageDescriptor) string {
+	if r.FixedIn == "" {
+		return ""
+	}
+	if pkg.Version != "" && CompareEcosystemVersions(pkg.Type, pkg.Version, r.FixedIn) >= 0 {
+		return ""
+	}
+	return "upgrade " + pkg.Name + " to >= " + r.FixedIn
+}
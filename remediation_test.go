@@ -0,0 +1,89 @@
+package phylum
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRemediation(t *testing.T) {
+	description := `qs before 6.11.1 allows prototype pollution (CVE-2022-24999).
+
+### Affected versions
+>= 6.0.0, < 6.11.1
+
+### Recommendation
+Upgrade to version 6.11.1 or later.
+
+### References
+- [Advisory](https://github.com/advisories/GHSA-hrpp-h998-j3pp)
+- https://nvd.nist.gov/vuln/detail/cve-2022-24999
+`
+	got := ParseRemediation(description)
+	want := &Remediation{
+		Overview:         "qs before 6.11.1 allows prototype pollution (CVE-2022-24999).",
+		AffectedVersions: ">= 6.0.0, < 6.11.1",
+		Recommendation:   "Upgrade to version 6.11.1 or later.",
+		References:       []string{"https://github.com/advisories/GHSA-hrpp-h998-j3pp", "https://nvd.nist.gov/vuln/detail/cve-2022-24999"},
+		CVEs:             []string{"CVE-2022-24999"},
+		FixedIn:          "6.11.1",
+		Sections: map[string]string{
+			"":                  "qs before 6.11.1 allows prototype pollution (CVE-2022-24999).",
+			"affected versions": ">= 6.0.0, < 6.11.1",
+			"recommendation":    "Upgrade to version 6.11.1 or later.",
+			"references":        "- [Advisory](https://github.com/advisories/GHSA-hrpp-h998-j3pp)\n- https://nvd.nist.gov/vuln/detail/cve-2022-24999",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRemediation() got = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseRemediationFixedIn(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		want        string
+	}{
+		{"upgrade to", "### Recommendation\nUpgrade to 1.3.9.", "1.3.9"},
+		{"upgrade package to version", "### Recommendation\nUpdate lodash to version v4.17.21", "4.17.21"},
+		{"fixed in", "### Recommendation\nThis was fixed in 2.0.0-rc.1.", "2.0.0-rc.1"},
+		{"minimum version", "### Recommendation\nUse >= 3.1", "3.1"},
+		{"or higher", "### Recommendation\nInstall version 5.0.2 or higher", "5.0.2"},
+		{"affected upper bound", "### Affected versions\n>= 1.0.0, < 1.4.2\n\n### Recommendation\nUpgrade.", "1.4.2"},
+		{"affected lower bound only", "### Affected versions\n>= 1.0.0\n\n### Recommendation\nRemove the package.", ""},
+		{"no recommendation", "A malicious package.", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseRemediation(tt.description).FixedIn; got != tt.want {
+				t.Errorf("ParseRemediation() FixedIn got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemediationSuggestion(t *testing.T) {
+	r := ParseRemediation("### Recommendation\nUpgrade to 6.11.1")
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"6.11.0", "upgrade qs to >= 6.11.1"},
+		{"6.11.1", ""},
+		{"", "upgrade qs to >= 6.11.1"},
+	}
+	for _, tt := range tests {
+		if got := r.Suggestion(PackageDescriptor{Name: "qs", Version: tt.version}); got != tt.want {
+			t.Errorf("Suggestion(%v) got = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+	if got := ParseRemediation("No fix.").Suggestion(PackageDescriptor{Name: "qs"}); got != "" {
+		t.Errorf("Suggestion() without a fixed version got = %q", got)
+	}
+
+	// Maven treats the Final qualifier as the release itself
+	hibernate := PackageDescriptor{Name: "org.hibernate:hibernate-core", Version: "5.6.15.Final", Type: Maven}
+	if got := ParseRemediation("### Recommendation\nUpgrade to 5.6.15").Suggestion(hibernate); got != "" {
+		t.Errorf("Suggestion(%v) got = %q, want no suggestion", hibernate.Version, got)
+	}
+}
@@ -0,0 +1,309 @@
+package phylum
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Report is the data rendered by the Markdown and HTML report templates
+type Report struct {
+	Project    string
+	Label      string
+	JobID      string
+	Score      float64
+	Pass       bool
+	Action     string
+	Domains    []ReportDomain
+	Packages   []ReportPackage // Sorted by score, riskiest first
+	Issues     []ReportIssue   // Grouped by issue, most severe first
+	Licenses   []ReportLicense // Sorted by number of packages
+	Categories map[LicenseCategory]int
+}
+
+// ReportDomain is the lowest score of any package in a risk domain
+type ReportDomain struct {
+	Domain    RiskDomain
+	Score     float64
+	Threshold float32 // Zero when the threshold is disabled
+}
+
+// Breached reports whether the domain score is below an enabled threshold
+func (d ReportDomain) Breached() bool {
+	return d.Threshold > 0 && d.Score < float64(d.Threshold)
+}
+
+// ReportPackage is a row of the package table
+type ReportPackage struct {
+	Name    string
+	Version string
+	Type    PackageType
+	Score   *float64
+	License string
+	Issues  int
+}
+
+// ReportIssue is an issue and the packages it affects
+type ReportIssue struct {
+	ID             string
+	Title          string
+	Severity       RiskLevel
+	Domain         RiskDomain
+	Recommendation string // Text of the "### Recommendation" section
+	Packages       []string
+}
+
+// ReportLicense counts the packages using a license
+type ReportLicense struct {
+	License  string
+	Category LicenseCategory
+	Count    int
+}
+
+// NewReport collects the data for a report on a verbose job. Domain scores are the lowest score
+// of any package in the domain.
+func NewReport(job *JobStatusResponseForPackageStatusExtended) *Report {
+	report := &Report{Categories: map[LicenseCategory]int{}}
+	if job == nil {
+		return report
+	}
+
+	report.Project = job.ProjectName
+	if job.Label != nil {
+		report.Label = *job.Label
+	}
+	report.JobID = job.JobId.String()
+	report.Score = job.Score
+	report.Pass = job.Pass
+	report.Action = string(JobAction(job))
+
+	issues := make(map[string]int)
+	licenses := make(map[string]int)
+
+	for _, status := range job.Packages {
+		pkg := ReportPackage{
+			Name:    status.Name,
+			Version: status.Version,
+			Type:    jobPackageType(status.Type),
+			Score:   status.PackageScore,
+			Issues:  len(status.Issues),
+		}
+		if status.License != nil {
+			pkg.License = *status.License
+		}
+		report.Packages = append(report.Packages, pkg)
+
+		for _, issue := range status.Issues {
+			key := sarifRuleID(issue)
+			name := status.Name + "@" + status.Version
+			if idx, ok := issues[key]; ok {
+				report.Issues[idx].Packages = append(report.Issues[idx].Packages, name)
+				continue
+			}
+			issues[key] = len(report.Issues)
+			report.Issues = append(report.Issues, ReportIssue{
+				ID:             key,
+				Title:          issue.Title,
+				Severity:       issue.Severity,
+				Domain:         issue.Domain,
+				Recommendation: ParseRemediation(issue.Description).Recommendation,
+				Packages:       []string{name},
+			})
+		}
+
+		license := CheckPackageLicense(PackageDescriptor{Name: status.Name, Version: status.Version}, status.License, nil)
+		name := "Unknown"
+		if license.Expression != nil {
+			name = license.Expression.String()
+		}
+		if _, ok := licenses[name]; !ok {
+			licenses[name] = len(report.Licenses)
+			report.Licenses = append(report.Licenses, ReportLicense{License: name, Category: license.Category})
+		}
+		report.Licenses[licenses[name]].Count++
+		report.Categories[license.Category]++
+	}
+
+	report.Domains = jobDomains(job)
+	sort.SliceStable(report.Packages, func(i, j int) bool {
+		a, b := report.Packages[i].Score, report.Packages[j].Score
+		return a != nil && (b == nil || *a < *b)
+	})
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return RiskLevelSeverity(report.Issues[i].Severity) > RiskLevelSeverity(report.Issues[j].Severity)
+	})
+	sort.SliceStable(report.Licenses, func(i, j int) bool {
+		return report.Licenses[i].Count > report.Licenses[j].Count
+	})
+	return report
+}
+
+// jobDomains returns the lowest score of any package in each risk domain scored by the job,
+// along with the job's threshold for the domain
+func jobDomains(job *JobStatusResponseForPackageStatusExtended) []ReportDomain {
+	scores := make(map[RiskDomain]float64)
+	for _, status := range job.Packages {
+		for key, score := range status.RiskVectors.AdditionalProperties {
+			domain, ok := riskVectorDomain(key)
+			if !ok {
+				continue
+			}
+			if current, seen := scores[domain]; !seen || score < current {
+				scores[domain] = score
+			}
+		}
+	}
+
+	var domains []ReportDomain
+	for _, domain := range []RiskDomain{RiskDomainAuthor, RiskDomainEngineering, RiskDomainLicense, RiskDomainMaliciousCode, RiskDomainVulnerability} {
+		if score, ok := scores[domain]; ok {
+			domains = append(domains, ReportDomain{Domain: domain, Score: score, Threshold: jobThreshold(job, domain)})
+		}
+	}
+	return domains
+}
+
+// ReportFuncs are the functions available to report templates
+var ReportFuncs = map[string]interface{}{
+	"score": func(score interface{}) string {
+		switch s := score.(type) {
+		case *float64:
+			if s == nil {
+				return "-"
+			}
+			return fmt.Sprintf("%.0f", *s*100)
+		case float64:
+			return fmt.Sprintf("%.0f", s*100)
+		case float32:
+			return fmt.Sprintf("%.0f", s*100)
+		}
+		return fmt.Sprint(score)
+	},
+	"join": strings.Join,
+	"upper": func(v interface{}) string {
+		return strings.ToUpper(fmt.Sprint(v))
+	},
+	"cell": func(v interface{}) string {
+		// Keeps a value inside a single Markdown table cell
+		s := strings.ReplaceAll(fmt.Sprint(v), "|", `\|`)
+		return strings.Join(strings.Fields(s), " ")
+	},
+	"status": func(pass bool) string {
+		if pass {
+			return "PASS"
+		}
+		return "FAIL"
+	},
+}
+
+// DefaultMarkdownReportTemplate is the text/template used by WriteMarkdown when none is given
+const DefaultMarkdownReportTemplate = `# Phylum report: {{.Project}}{{if .Label}} ({{.Label}}){{end}}
+
+| Job | Score | Result |
+| --- | --- | --- |
+| {{.JobID}} | {{score .Score}} | {{status .Pass}} |
+{{if .Domains}}
+## Risk domains
+
+| Domain | Score | Threshold |
+| --- | --- | --- |
+{{range .Domains}}| {{.Domain}} | {{score .Score}}{{if .Breached}} :x:{{end}} | {{if .Threshold}}{{score .Threshold}}{{else}}-{{end}} |
+{{end}}{{end}}
+## Packages
+
+| Package | Version | Ecosystem | Score | License | Issues |
+| --- | --- | --- | --- | --- | --- |
+{{range .Packages}}| {{cell .Name}} | {{cell .Version}} | {{.Type}} | {{score .Score}} | {{cell .License}} | {{.Issues}} |
+{{end}}{{if .Issues}}
+## Issues
+{{range .Issues}}
+### [{{upper .Severity}}] {{.Title}}
+
+Domain: {{.Domain}}. Affects {{join .Packages ", "}}.
+{{if .Recommendation}}
+{{.Recommendation}}
+{{end}}{{end}}{{end}}
+## Licenses
+
+| License | Category | Packages |
+| --- | --- | --- |
+{{range .Licenses}}| {{cell .License}} | {{.Category}} | {{.Count}} |
+{{end}}`
+
+// DefaultHTMLReportTemplate is the html/template used by WriteHTML when none is given. The page
+// is self-contained, with inline styles and no external assets.
+const DefaultHTMLReportTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Phylum report: {{.Project}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 70em; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; width: 100%; }
+th, td { border: 1px solid #ddd; padding: 0.4em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f5f5f5; }
+.fail { color: #b00020; font-weight: bold; }
+.pass { color: #1b7f3b; font-weight: bold; }
+.severity { font-weight: bold; text-transform: uppercase; }
+.recommendation { white-space: pre-wrap; background: #f8f8f8; padding: 0.6em; }
+</style>
+</head>
+<body>
+<h1>Phylum report: {{.Project}}{{if .Label}} ({{.Label}}){{end}}</h1>
+<table>
+<tr><th>Job</th><th>Score</th><th>Result</th></tr>
+<tr><td>{{.JobID}}</td><td>{{score .Score}}</td><td class="{{if .Pass}}pass{{else}}fail{{end}}">{{status .Pass}}</td></tr>
+</table>
+{{if .Domains}}<h2>Risk domains</h2>
+<table>
+<tr><th>Domain</th><th>Score</th><th>Threshold</th></tr>
+{{range .Domains}}<tr><td>{{.Domain}}</td><td{{if .Breached}} class="fail"{{end}}>{{score .Score}}</td><td>{{if .Threshold}}{{score .Threshold}}{{else}}-{{end}}</td></tr>
+{{end}}</table>
+{{end}}<h2>Packages</h2>
+<table>
+<tr><th>Package</th><th>Version</th><th>Ecosystem</th><th>Score</th><th>License</th><th>Issues</th></tr>
+{{range .Packages}}<tr><td>{{.Name}}</td><td>{{.Version}}</td><td>{{.Type}}</td><td>{{score .Score}}</td><td>{{.License}}</td><td>{{.Issues}}</td></tr>
+{{end}}</table>
+{{if .Issues}}<h2>Issues</h2>
+{{range .Issues}}<h3><span class="severity">{{.Severity}}</span> {{.Title}}</h3>
+<p>Domain: {{.Domain}}. Affects {{join .Packages ", "}}.</p>
+{{if .Recommendation}}<div class="recommendation">{{.Recommendation}}</div>
+{{end}}{{end}}{{end}}<h2>Licenses</h2>
+<table>
+<tr><th>License</th><th>Category</th><th>Packages</th></tr>
+{{range .Licenses}}<tr><td>{{.License}}</td><td>{{.Category}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`
+
+var (
+	defaultMarkdownReport = texttemplate.Must(texttemplate.New("report.md").Funcs(ReportFuncs).Parse(DefaultMarkdownReportTemplate))
+	defaultHTMLReport     = htmltemplate.Must(htmltemplate.New("report.html").Funcs(ReportFuncs).Parse(DefaultHTMLReportTemplate))
+)
+
+// WriteMarkdown renders the report with tmpl, or DefaultMarkdownReportTemplate when tmpl is nil.
+// Custom templates can use ReportFuncs.
+func (r *Report) WriteMarkdown(w io.Writer, tmpl *texttemplate.Template) error {
+	if tmpl == nil {
+		tmpl = defaultMarkdownReport
+	}
+	if err := tmpl.Execute(w, r); err != nil {
+		return fmt.Errorf("WriteMarkdown(): %w", err)
+	}
+	return nil
+}
+
+// WriteHTML renders the report with tmpl, or DefaultHTMLReportTemplate when tmpl is nil
+func (r *Report) WriteHTML(w io.Writer, tmpl *htmltemplate.Template) error {
+	if tmpl == nil {
+		tmpl = defaultHTMLReport
+	}
+	if err := tmpl.Execute(w, r); err != nil {
+		return fmt.Errorf("WriteHTML(): %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package phylum
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	texttemplate "text/template"
+)
+
+func testReportJob() *JobStatusResponseForPackageStatusExtended {
+	job := testSBOMJob()
+	job.Score = 0.4
+	job.Thresholds.Vulnerability = 0.6
+	low, high := 0.3, 0.9
+	job.Packages[0].PackageScore = &high
+	job.Packages[0].RiskVectors = PackageStatusExtended_RiskVectors{AdditionalProperties: map[string]float64{"vulnerability": 0.9}}
+	job.Packages[1].PackageScore = &low
+	job.Packages[1].RiskVectors = PackageStatusExtended_RiskVectors{AdditionalProperties: map[string]float64{"vulnerability": 0.3, "author": 0.8}}
+	return job
+}
+
+func TestNewReport(t *testing.T) {
+	report := NewReport(testReportJob())
+
+	var packages []string
+	for _, pkg := range report.Packages {
+		packages = append(packages, pkg.Name)
+	}
+	if want := []string{"qs", "express", "@types/node"}; !reflect.DeepEqual(packages, want) {
+		t.Errorf("NewReport() packages got = %v, want %v", packages, want)
+	}
+
+	wantDomains := []ReportDomain{
+		{Domain: RiskDomainAuthor, Score: 0.8},
+		{Domain: RiskDomainVulnerability, Score: 0.3, Threshold: 0.6},
+	}
+	if !reflect.DeepEqual(report.Domains, wantDomains) {
+		t.Errorf("NewReport() domains got = %+v, want %+v", report.Domains, wantDomains)
+	}
+	if !report.Domains[1].Breached() || report.Domains[0].Breached() {
+		t.Errorf("NewReport() Breached() got = %v, %v", report.Domains[0].Breached(), report.Domains[1].Breached())
+	}
+
+	wantIssues := []ReportIssue{{
+		ID:             "HV00001",
+		Title:          "Prototype pollution",
+		Severity:       High,
+		Domain:         RiskDomainVulnerability,
+		Recommendation: "Upgrade to 6.11.1",
+		Packages:       []string{"qs@6.11.0"},
+	}}
+	if !reflect.DeepEqual(report.Issues, wantIssues) {
+		t.Errorf("NewReport() issues got = %+v, want %+v", report.Issues, wantIssues)
+	}
+
+	if len(report.Licenses) != 3 || report.Categories[LicensePermissive] != 2 || report.Categories[LicenseUnknown] != 1 {
+		t.Errorf("NewReport() licenses got = %+v, categories %v", report.Licenses, report.Categories)
+	}
+}
+
+func TestReportWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewReport(testReportJob()).WriteMarkdown(&buf, nil); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# Phylum report: web (main)",
+		"| vulnerability | 30 :x: | 60 |",
+		"| qs | 6.11.0 | npm | 30 | BSD-3-Clause OR MIT | 1 |",
+		"### [HIGH] Prototype pollution",
+		"Upgrade to 6.11.1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteMarkdown() missing %q in:\n%s", want, out)
+		}
+	}
+
+	tmpl := texttemplate.Must(texttemplate.New("custom").Funcs(ReportFuncs).Parse("{{.Project}} {{score .Score}}"))
+	buf.Reset()
+	if err := NewReport(testReportJob()).WriteMarkdown(&buf, tmpl); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	if got := buf.String(); got != "web 40" {
+		t.Errorf("WriteMarkdown() custom template got = %q", got)
+	}
+}
+
+func TestReportWriteHTML(t *testing.T) {
+	job := testReportJob()
+	job.ProjectName = "<script>alert(1)</script>"
+	var buf bytes.Buffer
+	if err := NewReport(job).WriteHTML(&buf, nil); err != nil {
+		t.Fatalf("WriteHTML() error = %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "<script>") {
+		t.Errorf("WriteHTML() did not escape the project name")
+	}
+	for _, unwanted := range []string{"<link", "src=", "http://", "https://"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("WriteHTML() references an external asset: %q", unwanted)
+		}
+	}
+	if !strings.Contains(out, `<td class="fail">30</td>`) {
+		t.Errorf("WriteHTML() missing breached domain in:\n%s", out)
+	}
+}
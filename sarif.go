@@ -0,0 +1,265 @@
+package phylum
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// SARIFVersion is the SARIF specification version written by the exporter
+const SARIFVersion = "2.1.0"
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// SARIFOptions control where results are located
+type SARIFOptions struct {
+	Lockfile    string                    // Lockfile path, relative to the repository root, reported as the location of every result
+	Lines       map[PackageDescriptor]int // Line declaring each package, located by reading Lockfile when nil
+	ToolVersion string
+}
+
+// SARIFLog is a SARIF 2.1.0 log with a single run
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun is the output of one analysis
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool describes the analysis tool and its rules
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver is the tool component that produced the results
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule describes one kind of issue
+type SARIFRule struct {
+	ID                   string              `json:"id"`
+	ShortDescription     SARIFMessage        `json:"shortDescription"`
+	FullDescription      *SARIFMessage       `json:"fullDescription,omitempty"`
+	Help                 *SARIFMessage       `json:"help,omitempty"`
+	DefaultConfiguration SARIFConfiguration  `json:"defaultConfiguration"`
+	Properties           SARIFRuleProperties `json:"properties"`
+}
+
+// SARIFMessage is plain text with an optional Markdown rendering
+type SARIFMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+// SARIFConfiguration holds the default level of a rule
+type SARIFConfiguration struct {
+	Level string `json:"level"`
+}
+
+// SARIFRuleProperties carry the tags and security severity used by GitHub code scanning
+type SARIFRuleProperties struct {
+	Tags             []string `json:"tags,omitempty"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+}
+
+// SARIFResult is an issue found in one package
+type SARIFResult struct {
+	RuleID              string                `json:"ruleId"`
+	RuleIndex           int                   `json:"ruleIndex"`
+	Level               string                `json:"level"`
+	Message             SARIFMessage          `json:"message"`
+	Locations           []SARIFLocation       `json:"locations,omitempty"`
+	PartialFingerprints map[string]string     `json:"partialFingerprints,omitempty"`
+	Properties          SARIFResultProperties `json:"properties"`
+}
+
+// SARIFResultProperties identify the package a result was found in
+type SARIFResultProperties struct {
+	Package   string `json:"package"`
+	Version   string `json:"version"`
+	Ecosystem string `json:"ecosystem"`
+	Purl      string `json:"purl,omitempty"`
+}
+
+// SARIFLocation is where a result was found
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+// SARIFPhysicalLocation is a file and, when known, the line in it
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation is a file path relative to the repository root
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFRegion is a range of lines in a file
+type SARIFRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// NewSARIFFromJob converts the issues of a verbose job to SARIF results. Rules are keyed by issue
+// tag, or ID when the issue has no tag, and carry the parsed recommendation as their help text.
+// Results are located at the line of opts.Lockfile that declares the package.
+func NewSARIFFromJob(job *JobStatusResponseForPackageStatusExtended, opts *SARIFOptions) (*SARIFLog, error) {
+	if opts == nil {
+		opts = &SARIFOptions{}
+	}
+
+	run := SARIFRun{
+		Tool:    SARIFTool{Driver: SARIFDriver{Name: "Phylum", InformationURI: "https://phylum.io", Version: opts.ToolVersion, Rules: []SARIFRule{}}},
+		Results: []SARIFResult{},
+	}
+	if job == nil {
+		return &SARIFLog{Schema: sarifSchema, Version: SARIFVersion, Runs: []SARIFRun{run}}, nil
+	}
+
+	lines := opts.Lines
+	if lines == nil && opts.Lockfile != "" {
+		var packages []PackageDescriptor
+		for _, status := range job.Packages {
+			packages = append(packages, PackageDescriptor{Name: status.Name, Version: status.Version, Type: jobPackageType(status.Type)})
+		}
+		located, err := LocatePackages(opts.Lockfile, packages)
+		if err != nil {
+			return nil, fmt.Errorf("NewSARIFFromJob(): %w", err)
+		}
+		lines = located
+	}
+
+	ruleIndex := make(map[string]int)
+	for _, status := range job.Packages {
+		pkg := PackageDescriptor{Name: status.Name, Version: status.Version, Type: jobPackageType(status.Type)}
+		for _, issue := range status.Issues {
+			ruleID := sarifRuleID(issue)
+			idx, ok := ruleIndex[ruleID]
+			if !ok {
+				idx = len(run.Tool.Driver.Rules)
+				ruleIndex[ruleID] = idx
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule(ruleID, issue))
+			}
+			run.Results = append(run.Results, sarifResult(ruleID, idx, issue, pkg, opts.Lockfile, lines))
+		}
+	}
+	return &SARIFLog{Schema: sarifSchema, Version: SARIFVersion, Runs: []SARIFRun{run}}, nil
+}
+
+func sarifRuleID(issue Issue) string {
+	if issue.Tag != nil && *issue.Tag != "" {
+		return *issue.Tag
+	}
+	if issue.Id != nil && *issue.Id != "" {
+		return *issue.Id
+	}
+	return issue.Title
+}
+
+func sarifRule(ruleID string, issue Issue) SARIFRule {
+	rule := SARIFRule{
+		ID:                   ruleID,
+		ShortDescription:     SARIFMessage{Text: issue.Title},
+		DefaultConfiguration: SARIFConfiguration{Level: SARIFLevel(issue.Severity)},
+		Properties:           SARIFRuleProperties{SecuritySeverity: sarifSecuritySeverity(issue.Severity)},
+	}
+	if issue.Domain != "" {
+		rule.Properties.Tags = []string{string(issue.Domain)}
+	}
+
+	overview := issue.Description
+	if idx := strings.Index(overview, "### Recommendation"); idx >= 0 {
+		overview = overview[:idx]
+	}
+	if overview = strings.TrimSpace(overview); overview != "" {
+		rule.FullDescription = &SARIFMessage{Text: overview}
+	}
+	if recommendation := ParseRemediation(issue.Description).Recommendation; recommendation != "" {
+		rule.Help = &SARIFMessage{Text: recommendation, Markdown: recommendation}
+	}
+	return rule
+}
+
+func sarifResult(ruleID string, ruleIndex int, issue Issue, pkg PackageDescriptor, lockfile string, lines map[PackageDescriptor]int) SARIFResult {
+	purl := PackageURL(pkg)
+	result := SARIFResult{
+		RuleID:    ruleID,
+		RuleIndex: ruleIndex,
+		Level:     SARIFLevel(issue.Severity),
+		Message:   SARIFMessage{Text: fmt.Sprintf("%s@%s: %s", pkg.Name, pkg.Version, issue.Title)},
+		Properties: SARIFResultProperties{
+			Package:   pkg.Name,
+			Version:   pkg.Version,
+			Ecosystem: string(pkg.Type),
+			Purl:      purl,
+		},
+	}
+
+	if lockfile != "" {
+		location := SARIFLocation{PhysicalLocation: SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: filepath.ToSlash(lockfile)}}}
+		if line, ok := lines[pkg]; ok && line > 0 {
+			location.PhysicalLocation.Region = &SARIFRegion{StartLine: line}
+		}
+		result.Locations = []SARIFLocation{location}
+	}
+
+	// Fingerprints let code scanning track a result across runs even when lockfile lines move
+	sum := sha256.Sum256([]byte(ruleID + "\x00" + string(pkg.Type) + "\x00" + pkg.Name + "\x00" + pkg.Version))
+	result.PartialFingerprints = map[string]string{"phylumIssue/v1": hex.EncodeToString(sum[:16])}
+	return result
+}
+
+// SARIFLevel maps a RiskLevel to a SARIF result level
+func SARIFLevel(level RiskLevel) string {
+	switch level {
+	case Critical, High:
+		return "error"
+	case Medium:
+		return "warning"
+	case Low, Info:
+		return "note"
+	}
+	return "none"
+}
+
+// sarifSecuritySeverity maps a RiskLevel to the CVSS-like score GitHub uses to rank alerts
+func sarifSecuritySeverity(level RiskLevel) string {
+	switch level {
+	case Critical:
+		return "9.5"
+	case High:
+		return "8.0"
+	case Medium:
+		return "5.5"
+	case Low:
+		return "2.0"
+	case Info:
+		return "0.0"
+	}
+	return ""
+}
+
+// WriteJSON writes the log in the SARIF JSON format
+func (l *SARIFLog) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(l); err != nil {
+		return fmt.Errorf("WriteJSON(): %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package phylum
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNewSARIFFromJob(t *testing.T) {
+	tag, id := "HV00001", "b5d2b0a5-0ba9-4b3a-8d5e-3a8f1f4d9a1c"
+	job := &JobStatusResponseForPackageStatusExtended{
+		Packages: []PackageStatusExtended{
+			{
+				Name: "accepts", Version: "1.3.8", Type: "npm",
+				Issues: []Issue{
+					{Title: "Prototype pollution", Description: "Bad.\n\n### Recommendation\nUpgrade to 1.3.9\n\n### References\n- https://github.com/advisories", Tag: &tag, Domain: RiskDomainVulnerability, Severity: Critical},
+					{Title: "Unmaintained", Id: &id, Domain: RiskDomainAuthor, Severity: Low},
+				},
+			},
+			{
+				Name: "body-parser", Version: "1.19.2", Type: "npm",
+				Issues: []Issue{{Title: "Prototype pollution", Tag: &tag, Domain: RiskDomainVulnerability, Severity: Critical}},
+			},
+		},
+	}
+
+	log, err := NewSARIFFromJob(job, &SARIFOptions{Lockfile: filepath.Join("test_lockfiles", "package-lock.json")})
+	if err != nil {
+		t.Fatalf("NewSARIFFromJob() error = %v", err)
+	}
+	run := log.Runs[0]
+
+	var ruleIDs []string
+	for _, rule := range run.Tool.Driver.Rules {
+		ruleIDs = append(ruleIDs, rule.ID)
+	}
+	if !reflect.DeepEqual(ruleIDs, []string{tag, id}) {
+		t.Errorf("NewSARIFFromJob() rules got = %v", ruleIDs)
+	}
+	rule := run.Tool.Driver.Rules[0]
+	if rule.Help == nil || rule.Help.Text != "Upgrade to 1.3.9" || rule.FullDescription.Text != "Bad." || rule.Properties.SecuritySeverity != "9.5" {
+		t.Errorf("NewSARIFFromJob() rule got = %+v", rule)
+	}
+
+	if len(run.Results) != 3 {
+		t.Fatalf("NewSARIFFromJob() results got = %v, want 3", len(run.Results))
+	}
+	var levels, uris []string
+	var lines, indexes []int
+	for _, result := range run.Results {
+		levels = append(levels, result.Level)
+		indexes = append(indexes, result.RuleIndex)
+		location := result.Locations[0].PhysicalLocation
+		uris = append(uris, location.ArtifactLocation.URI)
+		lines = append(lines, location.Region.StartLine)
+	}
+	if !reflect.DeepEqual(levels, []string{"error", "note", "error"}) || !reflect.DeepEqual(indexes, []int{0, 1, 0}) {
+		t.Errorf("NewSARIFFromJob() levels got = %v, rule indexes %v", levels, indexes)
+	}
+	if !reflect.DeepEqual(lines, []int{35, 35, 52}) || uris[0] != "test_lockfiles/package-lock.json" {
+		t.Errorf("NewSARIFFromJob() locations got = %v %v", uris, lines)
+	}
+	if run.Results[0].PartialFingerprints["phylumIssue/v1"] == run.Results[2].PartialFingerprints["phylumIssue/v1"] {
+		t.Errorf("NewSARIFFromJob() fingerprints should differ between packages")
+	}
+
+	var out bytes.Buffer
+	if err := log.WriteJSON(&out); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || decoded["version"] != "2.1.0" || decoded["$schema"] == nil {
+		t.Errorf("WriteJSON() got = %v, err %v", decoded, err)
+	}
+}
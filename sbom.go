@@ -0,0 +1,109 @@
+package phylum
+
+import (
+	"sort"
+)
+
+// sbomPackage is a package with the metadata that SBOM formats can carry
+type sbomPackage struct {
+	PackageDescriptor
+	License *string
+	RepoUrl *string
+	Issues  []Issue
+}
+
+// sbomPackagesFromJob collects the packages of a verbose job
+func sbomPackagesFromJob(job *JobStatusResponseForPackageStatusExtended) []sbomPackage {
+	var pkgs []sbomPackage
+	for _, status := range job.Packages {
+		pkgs = append(pkgs, sbomPackage{
+			PackageDescriptor: PackageDescriptor{Name: status.Name, Version: status.Version, Type: jobPackageType(status.Type)},
+			License:           status.License,
+			Issues:            status.Issues,
+		})
+	}
+	return sortSBOMPackages(pkgs)
+}
+
+// sbomPackagesFromProject collects a project's dependencies, preferring detailed issues when present
+func sbomPackagesFromProject(project *ProjectResponse) []sbomPackage {
+	var pkgs []sbomPackage
+	for _, dep := range project.Dependencies {
+		issues := dep.IssuesDetails
+		if len(issues) == 0 {
+			for _, item := range dep.Issues {
+				issues = append(issues, issueFromListItem(item))
+			}
+		}
+		pkgs = append(pkgs, sbomPackage{
+			PackageDescriptor: PackageDescriptor{Name: dep.Name, Version: dep.Version, Type: PackageType(dep.Registry)},
+			License:           dep.License,
+			RepoUrl:           dep.RepoUrl,
+			Issues:            issues,
+		})
+	}
+	return sortSBOMPackages(pkgs)
+}
+
+func sbomPackagesFromDescriptors(packages []PackageDescriptor) []sbomPackage {
+	var pkgs []sbomPackage
+	for _, pkg := range DedupePackages(packages) {
+		pkgs = append(pkgs, sbomPackage{PackageDescriptor: pkg})
+	}
+	return sortSBOMPackages(pkgs)
+}
+
+// sortSBOMPackages orders packages by ecosystem, name and version so documents are reproducible
+func sortSBOMPackages(pkgs []sbomPackage) []sbomPackage {
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		a, b := pkgs[i], pkgs[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Version < b.Version
+	})
+	return pkgs
+}
+
+// issueFromListItem converts the summary issue of a project to an Issue
+func issueFromListItem(item IssuesListItem) Issue {
+	return Issue{
+		Title:       item.Title,
+		Description: item.Description,
+		Id:          item.Id,
+		Tag:         item.Tag,
+		Severity:    item.Impact,
+		Domain:      riskTypeDomain(item.RiskType),
+	}
+}
+
+// riskTypeDomain maps the RiskType of a project issue to its RiskDomain
+func riskTypeDomain(riskType RiskType) RiskDomain {
+	switch riskType {
+	case AuthorsRisk:
+		return RiskDomainAuthor
+	case EngineeringRisk:
+		return RiskDomainEngineering
+	case LicenseRisk:
+		return RiskDomainLicense
+	case MaliciousCodeRisk:
+		return RiskDomainMaliciousCode
+	case Vulnerabilities:
+		return RiskDomainVulnerability
+	}
+	return ""
+}
+
+// issueID identifies an issue by ID, falling back to its tag
+func issueID(issue Issue) string {
+	if issue.Id != nil && *issue.Id != "" {
+		return *issue.Id
+	}
+	if issue.Tag != nil {
+		return *issue.Tag
+	}
+	return ""
+}
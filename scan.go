@@ -0,0 +1,195 @@
+package phylum
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultScanSkipDirs are directory names ScanDirectory never descends into
+var DefaultScanSkipDirs = []string{".git", "node_modules", "vendor"}
+
+// ScanOptions controls which files ScanDirectory parses
+type ScanOptions struct {
+	Exclude      []string        // Gitignore style globs, relative to the scan root, for paths to skip
+	SkipDirs     []string        // Directory names to skip at any depth, DefaultScanSkipDirs when nil
+	NoGitignore  bool            // Don't honour .gitignore files found in the tree
+	ParseOptions *ParseOptions   // Options passed to each parser
+	Parsers      *ParserRegistry // Parsers used for detection, DefaultParserRegistry when nil
+}
+
+// ScannedLockfile is the result of parsing one lockfile found by ScanDirectory
+type ScannedLockfile struct {
+	Path      string      // Path relative to the scan root, using the OS separator
+	Parser    string      // Name of the LockfileParser that handled the file
+	Ecosystem PackageType // Ecosystem of the parsed packages, empty when none were found
+	Result    *ParseResult
+	Err       error // Parse error, in which case Result is nil
+}
+
+// ScanResult holds every lockfile found by ScanDirectory, ordered by path
+type ScanResult struct {
+	Root      string
+	Lockfiles []ScannedLockfile
+}
+
+// ScanDirectory walks root looking for lockfiles recognized by the parser registry and parses
+// each of them. Directories named in SkipDirs are never entered, and paths matched by .gitignore
+// files or by the Exclude globs are skipped. A lockfile that fails to parse is recorded with its
+// error instead of aborting the scan.
+func ScanDirectory(root string, opts *ScanOptions) (*ScanResult, error) {
+	if opts == nil {
+		opts = &ScanOptions{}
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("ScanDirectory(): %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("ScanDirectory(): %v is not a directory", root)
+	}
+
+	s := &directoryScanner{
+		root:     root,
+		opts:     opts,
+		registry: opts.Parsers,
+		skipDirs: opts.SkipDirs,
+		result:   &ScanResult{Root: root},
+	}
+	if s.registry == nil {
+		s.registry = DefaultParserRegistry
+	}
+	if s.skipDirs == nil {
+		s.skipDirs = DefaultScanSkipDirs
+	}
+	if s.parseOpts = opts.ParseOptions; s.parseOpts == nil {
+		s.parseOpts = &ParseOptions{Offline: true}
+	}
+	s.exclude = (*ignoreMatcher)(nil).withPatterns("", opts.Exclude)
+
+	if err := s.walk("", nil); err != nil {
+		return nil, fmt.Errorf("ScanDirectory(): %w", err)
+	}
+
+	sort.Slice(s.result.Lockfiles, func(i, j int) bool {
+		return s.result.Lockfiles[i].Path < s.result.Lockfiles[j].Path
+	})
+	return s.result, nil
+}
+
+type directoryScanner struct {
+	root      string
+	opts      *ScanOptions
+	registry  *ParserRegistry
+	parseOpts *ParseOptions
+	skipDirs  []string
+	exclude   *ignoreMatcher
+	result    *ScanResult
+}
+
+// walk scans the directory rel, a slash separated path relative to the root
+func (s *directoryScanner) walk(rel string, gitignore *ignoreMatcher) error {
+	dir := filepath.Join(s.root, filepath.FromSlash(rel))
+	if !s.opts.NoGitignore {
+		var err error
+		if gitignore, err = gitignore.withFile(rel, filepath.Join(dir, ".gitignore")); err != nil {
+			return err
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		entryRel := joinSlash(rel, entry.Name())
+		isDir := entry.IsDir()
+		if isDir && containsString(s.skipDirs, entry.Name()) {
+			continue
+		}
+		if s.exclude.ignored(entryRel, isDir) || gitignore.ignored(entryRel, isDir) {
+			continue
+		}
+
+		if isDir {
+			if err := s.walk(entryRel, gitignore); err != nil {
+				return err
+			}
+			continue
+		}
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		s.parse(entryRel)
+	}
+	return nil
+}
+
+// parse detects and parses one file. Files whose name no parser accepts are skipped without
+// being opened.
+func (s *directoryScanner) parse(rel string) {
+	parsers := s.registry.candidates(path.Base(rel))
+	if len(parsers) == 0 {
+		return
+	}
+	fullPath := filepath.Join(s.root, filepath.FromSlash(rel))
+	parser, err := detectLockfile(fullPath, parsers)
+	if errors.Is(err, ErrNoLockfileParser) {
+		return
+	}
+
+	scanned := ScannedLockfile{Path: filepath.FromSlash(rel)}
+	if err != nil {
+		scanned.Err = err
+		s.result.Lockfiles = append(s.result.Lockfiles, scanned)
+		return
+	}
+	scanned.Parser = parser.Name()
+
+	data, err := os.ReadFile(fullPath)
+	if err == nil {
+		scanned.Result, err = parser.Parse(fullPath, data, s.parseOpts)
+	}
+	if err != nil {
+		scanned.Err = fmt.Errorf("%s parser: %w", parser.Name(), err)
+	} else if len(scanned.Result.Packages) > 0 {
+		scanned.Ecosystem = scanned.Result.Packages[0].Type
+	}
+	s.result.Lockfiles = append(s.result.Lockfiles, scanned)
+}
+
+// Packages returns the packages from every lockfile merged into one deduplicated list, for
+// analysing the whole tree as a single project
+func (r *ScanResult) Packages() []PackageDescriptor {
+	var packages []PackageDescriptor
+	for _, lockfile := range r.Lockfiles {
+		if lockfile.Result != nil {
+			packages = append(packages, lockfile.Result.Packages...)
+		}
+	}
+	return DedupePackages(packages)
+}
+
+// ByEcosystem returns the deduplicated packages from every lockfile grouped by package type,
+// for analysing each ecosystem as its own project
+func (r *ScanResult) ByEcosystem() map[PackageType][]PackageDescriptor {
+	grouped := make(map[PackageType][]PackageDescriptor)
+	for _, pkg := range r.Packages() {
+		grouped[pkg.Type] = append(grouped[pkg.Type], pkg)
+	}
+	return grouped
+}
+
+// Errors returns the lockfiles that could not be parsed
+func (r *ScanResult) Errors() []ScannedLockfile {
+	var failed []ScannedLockfile
+	for _, lockfile := range r.Lockfiles {
+		if lockfile.Err != nil {
+			failed = append(failed, lockfile)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,138 @@
+package phylum
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScanDirectory(t *testing.T) {
+	root := t.TempDir()
+	copyFixture := func(fixture, dest string) {
+		data, err := os.ReadFile(filepath.Join("test_lockfiles", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(root, dest), string(data))
+	}
+
+	copyFixture("package-lock.json", "web/package-lock.json")
+	copyFixture("yarn.lock", "web/legacy/yarn.lock")
+	copyFixture("poetry.lock", "services/api/poetry.lock")
+	copyFixture("Gemfile.lock", "tools/Gemfile.lock")
+	copyFixture("yarn-v1.lock.bad", "broken/yarn.lock")
+	copyFixture("package-lock-v6.json", "web/node_modules/dep/package-lock.json")
+	copyFixture("Gemfile.lock", "vendor/bundle/Gemfile.lock")
+	copyFixture("poetry.lock", "build/poetry.lock")
+	copyFixture("Pipfile.lock", "services/worker/Pipfile.lock")
+	copyFixture("Pipfile.lock", "fixtures/Pipfile.lock")
+	writeFile(t, filepath.Join(root, ".gitignore"), "# build output\n/build/\n")
+	writeFile(t, filepath.Join(root, "services/.gitignore"), "worker/*.lock\n")
+	writeFile(t, filepath.Join(root, "README.md"), "# not a lockfile\n")
+
+	type args struct {
+		opts *ScanOptions
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantPaths []string
+		wantErrs  int
+	}{
+		{
+			"defaults",
+			args{&ScanOptions{Exclude: []string{"fixtures/**"}}},
+			[]string{"broken/yarn.lock", "services/api/poetry.lock", "tools/Gemfile.lock", "web/legacy/yarn.lock", "web/package-lock.json"},
+			1,
+		},
+		{
+			"no gitignore",
+			args{&ScanOptions{NoGitignore: true, Exclude: []string{"broken", "fixtures/"}}},
+			[]string{"build/poetry.lock", "services/api/poetry.lock", "services/worker/Pipfile.lock", "tools/Gemfile.lock", "web/legacy/yarn.lock", "web/package-lock.json"},
+			0,
+		},
+		{
+			"custom skip dirs",
+			args{&ScanOptions{SkipDirs: []string{"web", "broken", "fixtures"}}},
+			[]string{"services/api/poetry.lock", "tools/Gemfile.lock", "vendor/bundle/Gemfile.lock"},
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ScanDirectory(root, tt.args.opts)
+			if err != nil {
+				t.Fatalf("ScanDirectory() error = %v", err)
+			}
+			var gotPaths []string
+			for _, lockfile := range got.Lockfiles {
+				gotPaths = append(gotPaths, filepath.ToSlash(lockfile.Path))
+			}
+			if !reflect.DeepEqual(gotPaths, tt.wantPaths) {
+				t.Errorf("ScanDirectory() paths got = %v, want %v", gotPaths, tt.wantPaths)
+			}
+			if len(got.Errors()) != tt.wantErrs {
+				t.Errorf("ScanDirectory() errors got = %v, want %v", len(got.Errors()), tt.wantErrs)
+			}
+		})
+	}
+
+	got, err := ScanDirectory(root, &ScanOptions{Exclude: []string{"broken/", "fixtures/"}})
+	if err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	byEcosystem := got.ByEcosystem()
+	wantLen := map[PackageType]int{Npm: 55, Pypi: 45, Rubygems: 214}
+	for ecosystem, want := range wantLen {
+		if len(byEcosystem[ecosystem]) != want {
+			t.Errorf("ByEcosystem(): len of %v packages got = %v, want %v", ecosystem, len(byEcosystem[ecosystem]), want)
+		}
+	}
+	if len(got.Packages()) != 55+45+214 {
+		t.Errorf("Packages(): len got = %v, want %v", len(got.Packages()), 55+45+214)
+	}
+
+	if _, err := ScanDirectory(filepath.Join(root, "missing"), nil); err == nil {
+		t.Errorf("ScanDirectory() on missing root should fail")
+	}
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	matcher := (*ignoreMatcher)(nil).withPatterns("", []string{"*.log", "!keep.log", "/dist", "docs/**/draft-*", "tmp/"})
+	matcher = matcher.withPatterns("pkg", []string{"generated.json"})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"error.log", false, true},
+		{"nested/deep/error.log", false, true},
+		{"keep.log", false, false},
+		{"dist", true, true},
+		{"src/dist", true, false},
+		{"docs/draft-1.md", false, true},
+		{"docs/a/b/draft-2.md", false, true},
+		{"docs/final.md", false, false},
+		{"tmp", true, true},
+		{"tmp", false, false},
+		{"pkg/sub/generated.json", false, true},
+		{"generated.json", false, false},
+	}
+	for _, tt := range tests {
+		if got := matcher.ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, %v) got = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
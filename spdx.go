@@ -0,0 +1,344 @@
+package phylum
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SPDXVersion is the SPDX specification version written by the exporter
+const SPDXVersion = "SPDX-2.3"
+
+const (
+	spdxNoAssertion = "NOASSERTION"
+	spdxDocumentID  = "SPDXRef-DOCUMENT"
+	spdxRootID      = "SPDXRef-Project"
+)
+
+// SPDXOptions control the metadata of an exported SPDX document. Defaults are derived from the
+// project or job so the same input always produces the same document.
+type SPDXOptions struct {
+	Name      string    // Document name, defaults to the project name
+	Namespace string    // Document namespace, defaults to a URI derived from the name and packages
+	Creators  []string  // Defaults to "Tool: go-phylum"
+	Created   time.Time // Defaults to when the project was last updated or the job was created
+}
+
+// SPDXDocument is an SPDX 2.3 document. Its fields follow the JSON format; WriteTagValue writes
+// the tag-value format.
+type SPDXDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo   `json:"creationInfo"`
+	Packages          []SPDXPackage      `json:"packages"`
+	Relationships     []SPDXRelationship `json:"relationships"`
+}
+
+// SPDXCreationInfo records who created the document and when
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// SPDXPackage is a package in an SPDX document
+type SPDXPackage struct {
+	SPDXID                string            `json:"SPDXID"`
+	Name                  string            `json:"name"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	LicenseConcluded      string            `json:"licenseConcluded"`
+	LicenseDeclared       string            `json:"licenseDeclared"`
+	CopyrightText         string            `json:"copyrightText"`
+	ExternalRefs          []SPDXExternalRef `json:"externalRefs,omitempty"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+}
+
+// SPDXExternalRef links a package to an external identifier such as a purl
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+// SPDXRelationship relates two elements of the document, such as a DEPENDS_ON b
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// NewSPDXFromProject builds an SPDX document from the dependencies of a project, with DEPENDS_ON
+// relationships taken from their DepSpecs
+func NewSPDXFromProject(project *ProjectResponse, opts *SPDXOptions) *SPDXDocument {
+	o := SPDXOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if project == nil {
+		return newSPDX(nil, nil, &o)
+	}
+	if o.Name == "" {
+		o.Name = project.Name
+	}
+	if o.Created.IsZero() {
+		o.Created = project.CreatedAt
+		if project.UpdatedAt != nil {
+			o.Created = *project.UpdatedAt
+		}
+	}
+	return newSPDX(sbomPackagesFromProject(project), NewDependencyGraphFromProject(project), &o)
+}
+
+// NewSPDXFromJob builds an SPDX document from the packages of a verbose job
+func NewSPDXFromJob(job *JobStatusResponseForPackageStatusExtended, opts *SPDXOptions) *SPDXDocument {
+	o := SPDXOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if job == nil {
+		return newSPDX(nil, nil, &o)
+	}
+	if o.Name == "" {
+		o.Name = job.ProjectName
+	}
+	if o.Created.IsZero() {
+		o.Created = time.Unix(job.CreatedAt, 0)
+	}
+	return newSPDX(sbomPackagesFromJob(job), NewDependencyGraphFromJob(job), &o)
+}
+
+func newSPDX(pkgs []sbomPackage, graph *DependencyGraph, opts *SPDXOptions) *SPDXDocument {
+	name := opts.Name
+	if name == "" {
+		name = "phylum-project"
+	}
+	creators := opts.Creators
+	if len(creators) == 0 {
+		creators = []string{"Tool: go-phylum"}
+	}
+
+	doc := &SPDXDocument{
+		SPDXVersion:  SPDXVersion,
+		DataLicense:  "CC0-1.0",
+		SPDXID:       spdxDocumentID,
+		Name:         name,
+		CreationInfo: SPDXCreationInfo{Created: opts.Created.UTC().Format(time.RFC3339), Creators: creators},
+		Packages: []SPDXPackage{{
+			SPDXID:                spdxRootID,
+			Name:                  name,
+			DownloadLocation:      spdxNoAssertion,
+			LicenseConcluded:      spdxNoAssertion,
+			LicenseDeclared:       spdxNoAssertion,
+			CopyrightText:         spdxNoAssertion,
+			PrimaryPackagePurpose: "APPLICATION",
+		}},
+		Relationships: []SPDXRelationship{{SPDXElementID: spdxDocumentID, RelationshipType: "DESCRIBES", RelatedSPDXElement: spdxRootID}},
+	}
+
+	ids := make(map[PackageDescriptor]string, len(pkgs))
+	used := map[string]bool{spdxRootID: true}
+	hash := sha256.New()
+	for _, pkg := range pkgs {
+		id := spdxPackageID(pkg.PackageDescriptor, used)
+		ids[pkg.PackageDescriptor] = id
+		doc.Packages = append(doc.Packages, spdxPackage(pkg, id))
+		fmt.Fprintf(hash, "%s\x00%s\x00%s\n", pkg.Type, pkg.Name, pkg.Version)
+	}
+
+	doc.DocumentNamespace = opts.Namespace
+	if doc.DocumentNamespace == "" {
+		doc.DocumentNamespace = fmt.Sprintf("https://phylum.io/spdx/%s-%s", url.PathEscape(name), hex.EncodeToString(hash.Sum(nil))[:16])
+	}
+
+	if graph != nil {
+		for _, direct := range graph.DirectPackages() {
+			if id, ok := ids[direct]; ok {
+				doc.Relationships = append(doc.Relationships, SPDXRelationship{SPDXElementID: spdxRootID, RelationshipType: "DEPENDS_ON", RelatedSPDXElement: id})
+			}
+		}
+		for _, pkg := range pkgs {
+			for _, child := range graph.Dependencies(pkg.PackageDescriptor) {
+				if id, ok := ids[child]; ok {
+					doc.Relationships = append(doc.Relationships, SPDXRelationship{SPDXElementID: ids[pkg.PackageDescriptor], RelationshipType: "DEPENDS_ON", RelatedSPDXElement: id})
+				}
+			}
+		}
+	}
+	sortSPDXRelationships(doc.Relationships[1:])
+	return doc
+}
+
+func spdxPackage(pkg sbomPackage, id string) SPDXPackage {
+	result := SPDXPackage{
+		SPDXID:                id,
+		Name:                  pkg.Name,
+		VersionInfo:           pkg.Version,
+		DownloadLocation:      spdxDownloadLocation(pkg),
+		LicenseConcluded:      spdxNoAssertion,
+		LicenseDeclared:       spdxNoAssertion,
+		CopyrightText:         spdxNoAssertion,
+		PrimaryPackagePurpose: "LIBRARY",
+	}
+	if pkg.License != nil {
+		result.LicenseDeclared = spdxLicense(*pkg.License)
+	}
+	if purl := PackageURL(pkg.PackageDescriptor); purl != "" {
+		result.ExternalRefs = []SPDXExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: purl}}
+	}
+	return result
+}
+
+// spdxLicense returns the license as an SPDX expression, or NOASSERTION when it isn't valid SPDX
+// or names a license that isn't a known SPDX identifier
+func spdxLicense(license string) string {
+	expr, err := ParseLicenseExpression(license)
+	if err != nil {
+		return spdxNoAssertion
+	}
+	for _, id := range expr.Licenses() {
+		if LicenseCategoryOf(id) == LicenseUnknown {
+			return spdxNoAssertion
+		}
+	}
+	return expr.String()
+}
+
+// spdxPackageID builds a unique SPDX identifier from the package, which may only contain
+// letters, digits, "." and "-"
+func spdxPackageID(pkg PackageDescriptor, used map[string]bool) string {
+	var b strings.Builder
+	b.WriteString("SPDXRef-Package-")
+	for _, r := range string(pkg.Type) + "-" + pkg.Name + "-" + pkg.Version {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+
+	id := b.String()
+	for i := 2; used[id]; i++ {
+		id = fmt.Sprintf("%s-%d", b.String(), i)
+	}
+	used[id] = true
+	return id
+}
+
+// spdxDownloadLocation prefers the source repository and falls back to the registry artifact
+func spdxDownloadLocation(pkg sbomPackage) string {
+	if pkg.RepoUrl != nil && *pkg.RepoUrl != "" {
+		return *pkg.RepoUrl
+	}
+	if pkg.Version == "" {
+		return spdxNoAssertion
+	}
+
+	namespace, name := purlNamespace(pkg.PackageDescriptor)
+	switch pkg.Type {
+	case Npm:
+		return fmt.Sprintf("https://registry.npmjs.org/%s/-/%s-%s.tgz", pkg.Name, name, pkg.Version)
+	case Pypi:
+		return fmt.Sprintf("https://pypi.org/project/%s/%s/", name, pkg.Version)
+	case Maven:
+		if namespace == "" {
+			return spdxNoAssertion
+		}
+		return fmt.Sprintf("https://repo1.maven.org/maven2/%s/%s/%s/%s-%s.jar", strings.ReplaceAll(namespace, ".", "/"), name, pkg.Version, name, pkg.Version)
+	case Nuget:
+		id, version := strings.ToLower(pkg.Name), strings.ToLower(pkg.Version)
+		return fmt.Sprintf("https://api.nuget.org/v3-flatcontainer/%s/%s/%s.%s.nupkg", id, version, id, version)
+	case Rubygems:
+		return fmt.Sprintf("https://rubygems.org/downloads/%s-%s.gem", pkg.Name, pkg.Version)
+	}
+	return spdxNoAssertion
+}
+
+func sortSPDXRelationships(relationships []SPDXRelationship) {
+	key := func(r SPDXRelationship) string {
+		// The project's own relationships come first
+		prefix := "1"
+		if r.SPDXElementID == spdxRootID {
+			prefix = "0"
+		}
+		return prefix + r.SPDXElementID + "\x00" + r.RelationshipType + "\x00" + r.RelatedSPDXElement
+	}
+	sort.SliceStable(relationships, func(i, j int) bool {
+		return key(relationships[i]) < key(relationships[j])
+	})
+}
+
+// WriteJSON writes the document in the SPDX JSON format
+func (d *SPDXDocument) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(d); err != nil {
+		return fmt.Errorf("WriteJSON(): %w", err)
+	}
+	return nil
+}
+
+// WriteTagValue writes the document in the SPDX tag-value format
+func (d *SPDXDocument) WriteTagValue(w io.Writer) error {
+	var b strings.Builder
+	tag := func(name string, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", name, spdxTagValueText(value))
+		}
+	}
+
+	tag("SPDXVersion", d.SPDXVersion)
+	tag("DataLicense", d.DataLicense)
+	tag("SPDXID", d.SPDXID)
+	tag("DocumentName", d.Name)
+	tag("DocumentNamespace", d.DocumentNamespace)
+	for _, creator := range d.CreationInfo.Creators {
+		tag("Creator", creator)
+	}
+	tag("Created", d.CreationInfo.Created)
+
+	for _, pkg := range d.Packages {
+		fmt.Fprintf(&b, "\n##### Package: %s\n\n", pkg.Name)
+		tag("PackageName", pkg.Name)
+		tag("SPDXID", pkg.SPDXID)
+		tag("PackageVersion", pkg.VersionInfo)
+		tag("PackageDownloadLocation", pkg.DownloadLocation)
+		tag("FilesAnalyzed", fmt.Sprint(pkg.FilesAnalyzed))
+		tag("PackageLicenseConcluded", pkg.LicenseConcluded)
+		tag("PackageLicenseDeclared", pkg.LicenseDeclared)
+		tag("PackageCopyrightText", pkg.CopyrightText)
+		for _, ref := range pkg.ExternalRefs {
+			tag("ExternalRef", ref.ReferenceCategory+" "+ref.ReferenceType+" "+ref.ReferenceLocator)
+		}
+		tag("PrimaryPackagePurpose", pkg.PrimaryPackagePurpose)
+	}
+
+	if len(d.Relationships) > 0 {
+		b.WriteString("\n##### Relationships\n\n")
+	}
+	for _, r := range d.Relationships {
+		tag("Relationship", r.SPDXElementID+" "+r.RelationshipType+" "+r.RelatedSPDXElement)
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("WriteTagValue(): %w", err)
+	}
+	return nil
+}
+
+// spdxTagValueText wraps multi-line values in <text> tags as the tag-value format requires
+func spdxTagValueText(value string) string {
+	if strings.Contains(value, "\n") {
+		return "<text>" + value + "</text>"
+	}
+	return value
+}
@@ -0,0 +1,128 @@
+package phylum
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSPDXFromProject(t *testing.T) {
+	repo := "https://github.com/expressjs/express"
+	license := "MIT"
+	custom := "Acme Commercial License"
+	project := &ProjectResponse{
+		Name:      "web",
+		CreatedAt: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+		Dependencies: []FullPackageInternal{
+			{Name: "qs", Version: "6.11.0", Registry: "npm", License: &license},
+			{
+				Name: "express", Version: "4.18.2", Registry: "npm", RepoUrl: &repo, License: &license,
+				DepSpecs: []PackageSpecifier{{Name: "qs", Version: "6.11.0", Registry: "npm"}},
+			},
+			{Name: "org.yaml:snakeyaml", Version: "1.33", Registry: "maven", License: &custom},
+		},
+	}
+
+	doc := NewSPDXFromProject(project, nil)
+	if doc.CreationInfo.Created != "2023-01-02T03:04:05Z" || !strings.HasPrefix(doc.DocumentNamespace, "https://phylum.io/spdx/web-") {
+		t.Errorf("NewSPDXFromProject() creation info got = %+v, namespace %v", doc.CreationInfo, doc.DocumentNamespace)
+	}
+
+	var ids, locations []string
+	for _, pkg := range doc.Packages {
+		ids = append(ids, pkg.SPDXID)
+		locations = append(locations, pkg.DownloadLocation)
+	}
+	wantIDs := []string{"SPDXRef-Project", "SPDXRef-Package-maven-org.yaml-snakeyaml-1.33", "SPDXRef-Package-npm-express-4.18.2", "SPDXRef-Package-npm-qs-6.11.0"}
+	wantLocations := []string{
+		"NOASSERTION",
+		"https://repo1.maven.org/maven2/org/yaml/snakeyaml/1.33/snakeyaml-1.33.jar",
+		"https://github.com/expressjs/express",
+		"https://registry.npmjs.org/qs/-/qs-6.11.0.tgz",
+	}
+	if !reflect.DeepEqual(ids, wantIDs) || !reflect.DeepEqual(locations, wantLocations) {
+		t.Errorf("NewSPDXFromProject() packages got = %v %v, want %v %v", ids, locations, wantIDs, wantLocations)
+	}
+	if doc.Packages[1].LicenseDeclared != "NOASSERTION" || doc.Packages[2].LicenseDeclared != "MIT" {
+		t.Errorf("NewSPDXFromProject() licenses got = %v, %v", doc.Packages[1].LicenseDeclared, doc.Packages[2].LicenseDeclared)
+	}
+	if ref := doc.Packages[3].ExternalRefs[0]; ref.ReferenceType != "purl" || ref.ReferenceLocator != "pkg:npm/qs@6.11.0" {
+		t.Errorf("NewSPDXFromProject() external ref got = %+v", ref)
+	}
+
+	wantRelationships := []SPDXRelationship{
+		{"SPDXRef-DOCUMENT", "DESCRIBES", "SPDXRef-Project"},
+		{"SPDXRef-Project", "DEPENDS_ON", "SPDXRef-Package-maven-org.yaml-snakeyaml-1.33"},
+		{"SPDXRef-Project", "DEPENDS_ON", "SPDXRef-Package-npm-express-4.18.2"},
+		{"SPDXRef-Package-npm-express-4.18.2", "DEPENDS_ON", "SPDXRef-Package-npm-qs-6.11.0"},
+	}
+	if !reflect.DeepEqual(doc.Relationships, wantRelationships) {
+		t.Errorf("NewSPDXFromProject() relationships got = %v, want %v", doc.Relationships, wantRelationships)
+	}
+
+	// Reordering the input must not change the output
+	project.Dependencies[0], project.Dependencies[2] = project.Dependencies[2], project.Dependencies[0]
+	var first, second bytes.Buffer
+	if err := doc.WriteJSON(&first); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	if err := NewSPDXFromProject(project, nil).WriteJSON(&second); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	if first.String() != second.String() {
+		t.Errorf("WriteJSON() output depends on input order:\n%s\n%s", first.String(), second.String())
+	}
+	var decoded SPDXDocument
+	if err := json.Unmarshal(first.Bytes(), &decoded); err != nil || !reflect.DeepEqual(&decoded, doc) {
+		t.Errorf("WriteJSON() round trip got = %+v, err %v", decoded, err)
+	}
+}
+
+func TestSPDXLicense(t *testing.T) {
+	tests := []struct {
+		name    string
+		license string
+		want    string
+	}{
+		{"spdx id", "mit", "MIT"},
+		{"expression", "MIT OR Apache-2.0", "MIT OR Apache-2.0"},
+		{"custom name", "Acme Commercial License", "NOASSERTION"},
+		{"unknown id", "Proprietary", "NOASSERTION"},
+		{"unknown in expression", "MIT OR Proprietary", "NOASSERTION"},
+		{"invalid", "MIT OR", "NOASSERTION"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spdxLicense(tt.license); got != tt.want {
+				t.Errorf("spdxLicense() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSPDXDocument_WriteTagValue(t *testing.T) {
+	doc := NewSPDXFromJob(testSBOMJob(), &SPDXOptions{Namespace: "https://example.com/web"})
+
+	var out bytes.Buffer
+	if err := doc.WriteTagValue(&out); err != nil {
+		t.Fatalf("WriteTagValue() error = %v", err)
+	}
+	for _, want := range []string{
+		"SPDXVersion: SPDX-2.3\n",
+		"DocumentName: web\n",
+		"DocumentNamespace: https://example.com/web\n",
+		"Created: 1970-01-01T00:00:00Z\n",
+		"PackageName: @types/node\nSPDXID: SPDXRef-Package-npm--types-node-18.0.0\n",
+		"PackageDownloadLocation: https://registry.npmjs.org/@types/node/-/node-18.0.0.tgz\n",
+		"PackageLicenseDeclared: BSD-3-Clause OR MIT\n",
+		"ExternalRef: PACKAGE-MANAGER purl pkg:npm/express@4.18.2\n",
+		"Relationship: SPDXRef-Package-npm-express-4.18.2 DEPENDS_ON SPDXRef-Package-npm-qs-6.11.0\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("WriteTagValue() output is missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package phylum
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultSubmitChunkSize is the number of packages per job used by AnalyzePackagesInChunks when no size is given
+const DefaultSubmitChunkSize = 5000
+
+// PayloadTooLargeError is returned when a job submission exceeds the configured or server-side size limit
+type PayloadTooLargeError struct {
+	Size     int // Size of the encoded request body in bytes
+	Limit    int // Configured limit in bytes, 0 when the server rejected the request
+	Packages int // Number of packages in the submission
+}
+
+func (e *PayloadTooLargeError) Error() string {
+	if e.Limit > 0 {
+		return fmt.Sprintf("submission payload of %d bytes (%d packages) exceeds limit of %d bytes", e.Size, e.Packages, e.Limit)
+	}
+	return fmt.Sprintf("submission payload of %d bytes (%d packages) was rejected by the server as too large", e.Size, e.Packages)
+}
+
+// DedupePackages removes duplicate packages by (type, name, version), preserving the first occurrence order
+func DedupePackages(packages []PackageDescriptor) []PackageDescriptor {
+	seen := make(map[PackageDescriptor]bool, len(packages))
+	result := make([]PackageDescriptor, 0, len(packages))
+
+	for _, pkg := range packages {
+		if seen[pkg] {
+			continue
+		}
+		seen[pkg] = true
+		result = append(result, pkg)
+	}
+	return result
+}
+
+// ChunkPackages splits packages into consecutive slices of at most size elements
+func ChunkPackages(packages []PackageDescriptor, size int) [][]PackageDescriptor {
+	var chunks [][]PackageDescriptor
+	if size <= 0 {
+		size = DefaultSubmitChunkSize
+	}
+
+	for start := 0; start < len(packages); start += size {
+		end := start + size
+		if end > len(packages) {
+			end = len(packages)
+		}
+		chunks = append(chunks, packages[start:end])
+	}
+	return chunks
+}
+
+// encodeSubmitBody serializes a submission, gzipping it when enabled, and enforces MaxPayloadBytes
+func (p *PhylumClient) encodeSubmitBody(submitPackageRequest SubmitPackageRequest) ([]byte, map[string]string, error) {
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	body, err := json.Marshal(submitPackageRequest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encodeSubmitBody(): failed to marshal request: %w", err)
+	}
+
+	if p.GzipRequests {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err = zw.Write(body); err != nil {
+			return nil, nil, fmt.Errorf("encodeSubmitBody(): failed to gzip request: %w", err)
+		}
+		if err = zw.Close(); err != nil {
+			return nil, nil, fmt.Errorf("encodeSubmitBody(): failed to gzip request: %w", err)
+		}
+		body = buf.Bytes()
+		headers["Content-Encoding"] = "gzip"
+	}
+
+	if p.MaxPayloadBytes > 0 && len(body) > p.MaxPayloadBytes {
+		return nil, nil, &PayloadTooLargeError{Size: len(body), Limit: p.MaxPayloadBytes, Packages: len(submitPackageRequest.Packages)}
+	}
+
+	return body, headers, nil
+}
+
+// AnalyzePackagesInChunks deduplicates packages and submits them as one job per chunk of chunkSize packages.
+// Each job is labelled "<label> (i/n)". The returned job IDs can be combined with GetMergedJobVerbose.
+func (p *PhylumClient) AnalyzePackagesInChunks(submitPackageRequest SubmitPackageRequest, chunkSize int) ([]string, error) {
+	var jobIDs []string
+
+	chunks := ChunkPackages(DedupePackages(submitPackageRequest.Packages), chunkSize)
+	for i, chunk := range chunks {
+		chunkRequest := submitPackageRequest
+		chunkRequest.Packages = chunk
+		if len(chunks) > 1 {
+			chunkRequest.Label = strings.TrimSpace(fmt.Sprintf("%s (%d/%d)", submitPackageRequest.Label, i+1, len(chunks)))
+		}
+
+		jobID, err := p.submitPackages(chunkRequest)
+		if err != nil {
+			return jobIDs, fmt.Errorf("AnalyzePackagesInChunks(): chunk %d/%d failed: %w", i+1, len(chunks), err)
+		}
+		jobIDs = append(jobIDs, jobID)
+	}
+
+	return jobIDs, nil
+}
+
+// GetMergedJobVerbose fetches each job and merges the results into a single report
+func (p *PhylumClient) GetMergedJobVerbose(jobIDs []string) (*JobStatusResponseForPackageStatusExtended, error) {
+	var jobs []*JobStatusResponseForPackageStatusExtended
+
+	for _, jobID := range jobIDs {
+		job, _, err := p.GetJobVerbose(jobID)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return MergeJobResults(jobs...), nil
+}
+
+// MergeJobResults combines the verbose results of jobs produced from one split submission.
+// Packages are deduplicated, the merged job passes only if every job passed, the score is the
+// lowest job score, and the action is the most severe action of any job. Metadata is taken from
+// the first non-nil job.
+func MergeJobResults(jobs ...*JobStatusResponseForPackageStatusExtended) *JobStatusResponseForPackageStatusExtended {
+	var first *JobStatusResponseForPackageStatusExtended
+	for _, job := range jobs {
+		if job != nil {
+			first = job
+			break
+		}
+	}
+	if first == nil {
+		return nil
+	}
+
+	merged := *first
+	merged.Packages = nil
+	merged.Pass = true
+	merged.NumIncomplete = nil
+
+	action := ActionNone
+	complete := true
+	var numIncomplete uint32
+	seen := make(map[string]bool)
+
+	for _, job := range jobs {
+		if job == nil {
+			continue
+		}
+		if job.Score < merged.Score {
+			merged.Score = job.Score
+		}
+		if job.LastUpdated > merged.LastUpdated {
+			merged.LastUpdated = job.LastUpdated
+		}
+		merged.Pass = merged.Pass && job.Pass
+		complete = complete && JobComplete(job)
+		if job.NumIncomplete != nil {
+			numIncomplete += *job.NumIncomplete
+		}
+		if actionSeverity(JobAction(job)) > actionSeverity(action) {
+			action = JobAction(job)
+		}
+
+		for _, pkg := range job.Packages {
+			key := fmt.Sprintf("%v:%s@%s", pkg.Type, pkg.Name, pkg.Version)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged.Packages = append(merged.Packages, pkg)
+		}
+	}
+
+	merged.Action = string(action)
+	if complete {
+		merged.Status = string(Complete)
+	} else {
+		merged.Status = string(Incomplete)
+	}
+	if numIncomplete > 0 {
+		merged.NumIncomplete = &numIncomplete
+	}
+
+	return &merged
+}
+
+// actionSeverity orders actions from least to most severe
+func actionSeverity(action Action) int {
+	switch action {
+	case ActionBreak:
+		return 2
+	case ActionWarn:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,157 @@
+package phylum
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+)
+
+func TestDedupePackages(t *testing.T) {
+	packages := []PackageDescriptor{
+		{Name: "lodash", Version: "4.17.21", Type: Npm},
+		{Name: "react", Version: "18.2.0", Type: Npm},
+		{Name: "lodash", Version: "4.17.21", Type: Npm},
+		{Name: "lodash", Version: "4.17.20", Type: Npm},
+	}
+	want := []PackageDescriptor{
+		{Name: "lodash", Version: "4.17.21", Type: Npm},
+		{Name: "react", Version: "18.2.0", Type: Npm},
+		{Name: "lodash", Version: "4.17.20", Type: Npm},
+	}
+	if got := DedupePackages(packages); !reflect.DeepEqual(got, want) {
+		t.Errorf("DedupePackages() got = %v, want %v", got, want)
+	}
+}
+
+func TestChunkPackages(t *testing.T) {
+	packages := make([]PackageDescriptor, 7)
+	tests := []struct {
+		name    string
+		size    int
+		wantLen []int
+	}{
+		{"exact", 7, []int{7}},
+		{"split", 3, []int{3, 3, 1}},
+		{"default", 0, []int{7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotLen []int
+			for _, chunk := range ChunkPackages(packages, tt.size) {
+				gotLen = append(gotLen, len(chunk))
+			}
+			if !reflect.DeepEqual(gotLen, tt.wantLen) {
+				t.Errorf("ChunkPackages() got = %v, want %v", gotLen, tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestPhylumClient_encodeSubmitBody(t *testing.T) {
+	req := SubmitPackageRequest{
+		Packages: []PackageDescriptor{{Name: "lodash", Version: "4.17.21", Type: Npm}},
+		Project:  "42b07f68-cf1c-42c8-a217-ce2d903c22b5",
+		Type:     "npm",
+	}
+
+	p := &PhylumClient{GzipRequests: true}
+	body, headers, err := p.encodeSubmitBody(req)
+	if err != nil {
+		t.Fatalf("encodeSubmitBody() error = %v", err)
+	}
+	if headers["Content-Encoding"] != "gzip" {
+		t.Errorf("encodeSubmitBody() missing gzip Content-Encoding header")
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	plain, _ := io.ReadAll(zr)
+	var decoded SubmitPackageRequest
+	if err = json.Unmarshal(plain, &decoded); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Packages, req.Packages) {
+		t.Errorf("encodeSubmitBody() packages got = %v, want %v", decoded.Packages, req.Packages)
+	}
+
+	p = &PhylumClient{MaxPayloadBytes: 10}
+	_, _, err = p.encodeSubmitBody(req)
+	var tooLarge *PayloadTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("encodeSubmitBody() error = %v, want PayloadTooLargeError", err)
+	}
+	if tooLarge.Size <= 10 || tooLarge.Packages != 1 {
+		t.Errorf("encodeSubmitBody() error got = %+v", tooLarge)
+	}
+}
+
+func TestPhylumClient_submitPackagesTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	}))
+	defer server.Close()
+
+	p := &PhylumClient{Client: resty.New(), ApiUrl: server.URL, MaxPayloadBytes: 1 << 20}
+	_, err := p.submitPackages(SubmitPackageRequest{
+		Packages: []PackageDescriptor{{Name: "lodash", Version: "4.17.21", Type: Npm}},
+		Type:     "npm",
+	})
+	var tooLarge *PayloadTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("submitPackages() error = %v, want PayloadTooLargeError", err)
+	}
+	if tooLarge.Limit != 0 || tooLarge.Size == 0 || tooLarge.Packages != 1 {
+		t.Errorf("submitPackages() error got = %+v, want a server-side rejection", tooLarge)
+	}
+}
+
+func TestMergeJobResults(t *testing.T) {
+	one := uint32(1)
+	jobA := &JobStatusResponseForPackageStatusExtended{
+		Action:   "warn",
+		Pass:     true,
+		Score:    0.8,
+		Status:   "complete",
+		Packages: []PackageStatusExtended{{Name: "a", Version: "1.0.0"}, {Name: "b", Version: "1.0.0"}},
+	}
+	jobB := &JobStatusResponseForPackageStatusExtended{
+		Action:        "break",
+		Pass:          false,
+		Score:         0.5,
+		Status:        "incomplete",
+		NumIncomplete: &one,
+		Packages:      []PackageStatusExtended{{Name: "b", Version: "1.0.0"}, {Name: "c", Version: "2.0.0"}},
+	}
+
+	got := MergeJobResults(jobA, jobB)
+	if len(got.Packages) != 3 {
+		t.Errorf("MergeJobResults() len(Packages) got = %v, want 3", len(got.Packages))
+	}
+	if got.Pass {
+		t.Errorf("MergeJobResults() Pass got = true, want false")
+	}
+	if got.Score != 0.5 {
+		t.Errorf("MergeJobResults() Score got = %v, want 0.5", got.Score)
+	}
+	if JobAction(got) != ActionBreak {
+		t.Errorf("MergeJobResults() Action got = %v, want %v", got.Action, ActionBreak)
+	}
+	if JobComplete(got) {
+		t.Errorf("MergeJobResults() Status got = complete, want incomplete")
+	}
+	if got.NumIncomplete == nil || *got.NumIncomplete != 1 {
+		t.Errorf("MergeJobResults() NumIncomplete got = %v, want 1", got.NumIncomplete)
+	}
+	if len(jobA.Packages) != 2 {
+		t.Errorf("MergeJobResults() modified its input")
+	}
+}
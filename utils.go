@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -44,3 +45,23 @@ func GetTokenFromCLI() (string, error) {
 	}
 	return strings.TrimSuffix(string(output), "\n"), nil
 }
+
+// sortedKeys returns the keys of a string-keyed map in sorted order
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// containsString reports whether s is an element of list
+func containsString(list []string, s string) bool {
+	for _, elem := range list {
+		if elem == s {
+			return true
+		}
+	}
+	return false
+}
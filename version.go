@@ -0,0 +1,509 @@
+package phylum
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CompareVersions orders two version strings, returning -1, 0 or 1. Versions are compared
+// segment by segment, numerically where both segments are numbers, and missing trailing
+// segments count as zero so 1.0 equals 1.0.0. A prerelease suffix after "-" orders before the
+// release it precedes and build metadata after "+" is ignored.
+func CompareVersions(a string, b string) int {
+	mainA, preA := splitVersion(a)
+	mainB, preB := splitVersion(b)
+
+	if c := compareVersionSegments(mainA, mainB); c != 0 {
+		return c
+	}
+	switch {
+	case preA == "" && preB == "":
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return compareVersionSegments(preA, preB)
+}
+
+// splitVersion separates the release and prerelease parts of a version
+func splitVersion(version string) (string, string) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if idx := strings.Index(version, "+"); idx >= 0 {
+		version = version[:idx]
+	}
+	if idx := strings.Index(version, "-"); idx >= 0 {
+		return version[:idx], version[idx+1:]
+	}
+	return version, ""
+}
+
+func compareVersionSegments(a string, b string) int {
+	segmentsA, segmentsB := versionSegments(a), versionSegments(b)
+	for i := 0; i < len(segmentsA) || i < len(segmentsB); i++ {
+		segA, segB := "0", "0"
+		if i < len(segmentsA) {
+			segA = segmentsA[i]
+		}
+		if i < len(segmentsB) {
+			segB = segmentsB[i]
+		}
+
+		numA, errA := strconv.ParseUint(segA, 10, 64)
+		numB, errB := strconv.ParseUint(segB, 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				if numA < numB {
+					return -1
+				}
+				return 1
+			}
+		case errA == nil:
+			// Numbers sort after words, so 1.0.1 > 1.0.beta
+			return 1
+		case errB == nil:
+			return -1
+		default:
+			if c := strings.Compare(strings.ToLower(segA), strings.ToLower(segB)); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// versionSegments splits a version into runs of digits and runs of letters, dropping separators
+func versionSegments(version string) []string {
+	var segments []string
+	var current strings.Builder
+	digits := false
+	flush := func() {
+		if current.Len() > 0 {
+			segments = append(segments, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range version {
+		switch {
+		case unicode.IsDigit(r):
+			if !digits {
+				flush()
+			}
+			digits = true
+			current.WriteRune(r)
+		case unicode.IsLetter(r):
+			if digits {
+				flush()
+			}
+			digits = false
+			current.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return segments
+}
+
+type versionComparator struct {
+	op      string
+	version string
+}
+
+func (c versionComparator) matches(pkgType PackageType, version string) bool {
+	cmp := CompareEcosystemVersions(pkgType, version, c.version)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case "!=":
+		return cmp != 0
+	default:
+		return cmp == 0
+	}
+}
+
+// VersionRange is a set of versions such as ">=1.0.0 <2.0.0 || 3.1.0". Comparators separated by
+// spaces or commas must all match, and alternatives are separated by "||". The zero value and "*"
+// match every version.
+type VersionRange struct {
+	raw          string
+	alternatives [][]versionComparator
+}
+
+// ParseVersionRange parses a version range. Supported operators are =, ==, !=, <, <=, > and >=;
+// a bare version matches exactly.
+func ParseVersionRange(s string) (VersionRange, error) {
+	r := VersionRange{raw: strings.TrimSpace(s)}
+	if r.raw == "" || r.raw == "*" {
+		return r, nil
+	}
+
+	for _, alternative := range strings.Split(r.raw, "||") {
+		tokens := strings.FieldsFunc(alternative, func(c rune) bool { return c == ',' || unicode.IsSpace(c) })
+		if len(tokens) == 0 {
+			return VersionRange{}, fmt.Errorf("empty alternative in version range %q", s)
+		}
+
+		var comparators []versionComparator
+		for i := 0; i < len(tokens); i++ {
+			token := tokens[i]
+			op := token[:len(token)-len(strings.TrimLeft(token, "<>=!"))]
+			version := token[len(op):]
+			if version == "" {
+				// Operator separated from its version by whitespace
+				if i+1 >= len(tokens) {
+					return VersionRange{}, fmt.Errorf("operator %q has no version in version range %q", op, s)
+				}
+				i++
+				version = tokens[i]
+			}
+			switch op {
+			case "", "=", "==":
+				op = "="
+			case "<", "<=", ">", ">=", "!=":
+			default:
+				return VersionRange{}, fmt.Errorf("unknown operator %q in version range %q", op, s)
+			}
+			if version == "*" {
+				continue
+			}
+			if r := rune(version[0]); !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return VersionRange{}, fmt.Errorf("invalid version %q in version range %q", version, s)
+			}
+			comparators = append(comparators, versionComparator{op: op, version: version})
+		}
+		r.alternatives = append(r.alternatives, comparators)
+	}
+	return r, nil
+}
+
+// Contains reports whether version of a package of pkgType is in the range, comparing versions
+// with the rules of its ecosystem
+func (r VersionRange) Contains(pkgType PackageType, version string) bool {
+	if len(r.alternatives) == 0 {
+		return true
+	}
+	for _, comparators := range r.alternatives {
+		matched := true
+		for _, comparator := range comparators {
+			if !comparator.matches(pkgType, version) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAny reports whether the range matches every version
+func (r VersionRange) IsAny() bool {
+	return len(r.alternatives) == 0
+}
+
+// String returns the range as it was written
+func (r VersionRange) String() string {
+	return r.raw
+}
+
+// CompareEcosystemVersions orders two versions using the rules of an ecosystem: SemVer for npm,
+// PEP 440 for pypi, Maven's ComparableVersion ordering, NuGet's four-part SemVer and RubyGems'
+// Gem::Version. Other ecosystems use CompareVersions.
+func CompareEcosystemVersions(pkgType PackageType, a string, b string) int {
+	switch pkgType {
+	case Npm:
+		return compareSemver(a, b, false)
+	case Nuget:
+		return compareSemver(a, b, true)
+	case Pypi:
+		return comparePEP440(a, b)
+	case Maven:
+		return compareMaven(a, b)
+	case Rubygems:
+		return compareRubyGems(a, b)
+	}
+	return CompareVersions(a, b)
+}
+
+func compareInts(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareSemver orders SemVer 2.0 versions. Prerelease identifiers are compared field by field,
+// numeric identifiers numerically and before alphanumeric ones; NuGet compares them ignoring case.
+func compareSemver(a string, b string, ignoreCase bool) int {
+	mainA, preA := splitVersion(a)
+	mainB, preB := splitVersion(b)
+	if c := compareVersionSegments(mainA, mainB); c != 0 {
+		return c
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	if ignoreCase {
+		preA, preB = strings.ToLower(preA), strings.ToLower(preB)
+	}
+
+	idsA, idsB := strings.Split(preA, "."), strings.Split(preB, ".")
+	for i := 0; i < len(idsA) && i < len(idsB); i++ {
+		numA, errA := strconv.ParseUint(idsA[i], 10, 64)
+		numB, errB := strconv.ParseUint(idsB[i], 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				if numA < numB {
+					return -1
+				}
+				return 1
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(idsA[i], idsB[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(idsA), len(idsB))
+}
+
+var pep440Pattern = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d*))?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+	`(?:[-_.]?(dev)[-_.]?(\d*))?` +
+	`(?:\+[a-z0-9]+(?:[-_.][a-z0-9]+)*)?$`)
+
+// pep440Version is a parsed PEP 440 version. Missing pre, post and dev parts are -1.
+type pep440Version struct {
+	epoch   int
+	release []int
+	preKind int // 0 a, 1 b, 2 rc
+	pre     int
+	post    int
+	dev     int
+}
+
+func parsePEP440(version string) (pep440Version, bool) {
+	m := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(version)))
+	if m == nil {
+		return pep440Version{}, false
+	}
+	number := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	v := pep440Version{epoch: number(m[1]), pre: -1, post: -1, dev: -1}
+	for _, part := range strings.Split(m[2], ".") {
+		v.release = append(v.release, number(part))
+	}
+	for len(v.release) > 1 && v.release[len(v.release)-1] == 0 {
+		v.release = v.release[:len(v.release)-1]
+	}
+	if m[3] != "" {
+		switch m[3] {
+		case "a", "alpha":
+			v.preKind = 0
+		case "b", "beta":
+			v.preKind = 1
+		default:
+			v.preKind = 2
+		}
+		v.pre = number(m[4])
+	}
+	switch {
+	case m[5] != "":
+		v.post = number(m[5])
+	case m[6] != "":
+		v.post = number(m[7])
+	}
+	if m[8] != "" {
+		v.dev = number(m[9])
+	}
+	return v, true
+}
+
+// comparePEP440 orders versions as PEP 440 does: 1.0.dev0 < 1.0a1 < 1.0rc1 < 1.0 < 1.0.post1.
+// Local version labels are ignored. Versions that are not valid PEP 440 fall back to
+// CompareVersions.
+func comparePEP440(a string, b string) int {
+	va, okA := parsePEP440(a)
+	vb, okB := parsePEP440(b)
+	if !okA || !okB {
+		return CompareVersions(a, b)
+	}
+
+	if c := compareInts(va.epoch, vb.epoch); c != 0 {
+		return c
+	}
+	for i := 0; i < len(va.release) || i < len(vb.release); i++ {
+		ra, rb := 0, 0
+		if i < len(va.release) {
+			ra = va.release[i]
+		}
+		if i < len(vb.release) {
+			rb = vb.release[i]
+		}
+		if c := compareInts(ra, rb); c != 0 {
+			return c
+		}
+	}
+
+	// A development release of a final version sorts before its prereleases, and a final
+	// version after them
+	preKey := func(v pep440Version) [2]int {
+		switch {
+		case v.pre < 0 && v.post < 0 && v.dev >= 0:
+			return [2]int{-1, 0}
+		case v.pre < 0:
+			return [2]int{3, 0}
+		}
+		return [2]int{v.preKind, v.pre}
+	}
+	pa, pb := preKey(va), preKey(vb)
+	if c := compareInts(pa[0], pb[0]); c != 0 {
+		return c
+	}
+	if c := compareInts(pa[1], pb[1]); c != 0 {
+		return c
+	}
+	if c := compareInts(va.post, vb.post); c != 0 {
+		return c
+	}
+
+	devKey := func(v pep440Version) int {
+		if v.dev < 0 {
+			return int(^uint(0) >> 1)
+		}
+		return v.dev
+	}
+	return compareInts(devKey(va), devKey(vb))
+}
+
+// mavenQualifiers ranks the well-known Maven qualifiers; the empty qualifier is a release
+var mavenQualifiers = map[string]int{
+	"alpha":     0,
+	"a":         0,
+	"beta":      1,
+	"b":         1,
+	"milestone": 2,
+	"m":         2,
+	"rc":        3,
+	"cr":        3,
+	"snapshot":  4,
+	"":          5,
+	"ga":        5,
+	"final":     5,
+	"release":   5,
+	"sp":        6,
+}
+
+func compareMavenQualifiers(a string, b string) int {
+	rankA, knownA := mavenQualifiers[a]
+	rankB, knownB := mavenQualifiers[b]
+	switch {
+	case knownA && knownB:
+		return compareInts(rankA, rankB)
+	case knownA:
+		// Unknown qualifiers sort after all known ones
+		return -1
+	case knownB:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// compareMaven orders versions as Maven's ComparableVersion does: numbers compare numerically
+// and after qualifiers, and qualifiers order alpha < beta < milestone < rc < snapshot < release
+// < sp, with unknown qualifiers last. Missing items count as 0 or as a release.
+func compareMaven(a string, b string) int {
+	segmentsA := versionSegments(strings.ToLower(a))
+	segmentsB := versionSegments(strings.ToLower(b))
+	for i := 0; i < len(segmentsA) || i < len(segmentsB); i++ {
+		segA, segB := "", ""
+		if i < len(segmentsA) {
+			segA = segmentsA[i]
+		}
+		if i < len(segmentsB) {
+			segB = segmentsB[i]
+		}
+
+		numA, errA := strconv.ParseUint(segA, 10, 64)
+		numB, errB := strconv.ParseUint(segB, 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				if numA < numB {
+					return -1
+				}
+				return 1
+			}
+		case errA == nil && segB == "":
+			if numA != 0 {
+				return 1
+			}
+		case errB == nil && segA == "":
+			if numB != 0 {
+				return -1
+			}
+		case errA == nil:
+			return 1
+		case errB == nil:
+			return -1
+		default:
+			if c := compareMavenQualifiers(segA, segB); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// compareRubyGems orders versions as Gem::Version does: a "-" starts a prerelease, and any
+// segment containing letters makes the version a prerelease of the segments before it
+func compareRubyGems(a string, b string) int {
+	return compareVersionSegments(strings.ReplaceAll(a, "-", ".pre."), strings.ReplaceAll(b, "-", ".pre."))
+}
+
+// majorVersion is the first non-zero release segment of a version and its position, so
+// that 0.x minor releases count as major bumps as they do for npm caret ranges
+func majorVersion(version string) (int, uint64) {
+	main, _ := splitVersion(version)
+	for i, segment := range versionSegments(main) {
+		n, err := strconv.ParseUint(segment, 10, 64)
+		if err != nil {
+			break
+		}
+		if n != 0 {
+			return i, n
+		}
+	}
+	return -1, 0
+}
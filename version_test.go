@@ -0,0 +1,112 @@
+package phylum
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0", "1.0.0", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.10", "1.2.9", 1},
+		{"1.10.0", "1.9.9", 1},
+		{"2.0.0-beta.1", "2.0.0", -1},
+		{"2.0.0-beta.2", "2.0.0-beta.10", -1},
+		{"2.0.0-alpha", "2.0.0-beta", -1},
+		{"1.0.0+build.5", "1.0.0", 0},
+		{"4.17.20", "4.17.21", -1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) got = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestVersionRange_Contains(t *testing.T) {
+	tests := []struct {
+		versionRange string
+		pkgType      PackageType
+		version      string
+		want         bool
+		wantErr      bool
+	}{
+		{"", "", "1.0.0", true, false},
+		{"*", "", "1.0.0", true, false},
+		{"1.2.3", "", "1.2.3", true, false},
+		{"==1.2.3", "", "1.2.4", false, false},
+		{">=1.0.0 <2.0.0", "", "1.5.0", true, false},
+		{">=1.0.0, <2.0.0", "", "2.0.0", false, false},
+		{">= 1.0.0 < 2.0.0", "", "0.9.0", false, false},
+		{"<1.0.0 || >=3.0.0", "", "3.1.0", true, false},
+		{"<1.0.0 || >=3.0.0", "", "2.0.0", false, false},
+		{"!=1.2.3", "", "1.2.3", false, false},
+		{">=", "", "", false, true},
+		{"=>1.0.0", "", "", false, true},
+		{"1.0.0 ||", "", "", false, true},
+		{"<1.0.0", Maven, "1.0.0.Final", false, false},
+		{"==1.0.0", Maven, "1.0.0.Final", true, false},
+		{">=1.0", Pypi, "1.0rc1", false, false},
+	}
+	for _, tt := range tests {
+		r, err := ParseVersionRange(tt.versionRange)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseVersionRange(%q) error = %v, wantErr %v", tt.versionRange, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := r.Contains(tt.pkgType, tt.version); got != tt.want {
+			t.Errorf("ParseVersionRange(%q).Contains(%v, %q) got = %v, want %v", tt.versionRange, tt.pkgType, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestCompareEcosystemVersions(t *testing.T) {
+	tests := []struct {
+		pkgType PackageType
+		a, b    string
+		want    int
+	}{
+		{Npm, "1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{Npm, "1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{Npm, "1.0.0-beta.11", "1.0.0-rc.1", -1},
+		{Npm, "1.0.0-rc.1", "1.0.0", -1},
+		{Npm, "1.0.0+build", "1.0.0", 0},
+		{Nuget, "1.0.0.1", "1.0.0", 1},
+		{Nuget, "1.0.0-Beta", "1.0.0-beta", 0},
+		{Pypi, "1.0.dev1", "1.0a1", -1},
+		{Pypi, "1.0a1", "1.0b1", -1},
+		{Pypi, "1.0rc1", "1.0", -1},
+		{Pypi, "1.0", "1.0.post1", -1},
+		{Pypi, "1.0.post1.dev1", "1.0.post1", -1},
+		{Pypi, "1.0-1", "1.0.post1", 0},
+		{Pypi, "1.0.0", "1.0", 0},
+		{Pypi, "1!0.1", "2.0", 1},
+		{Pypi, "1.0+local.1", "1.0", 0},
+		{Pypi, "2.0.0alpha", "2.0.0a0", 0},
+		{Maven, "1.0-alpha-1", "1.0", -1},
+		{Maven, "1.0-SNAPSHOT", "1.0", -1},
+		{Maven, "1.0-rc1", "1.0-SNAPSHOT", -1},
+		{Maven, "1.0", "1.0.0", 0},
+		{Maven, "1.0.Final", "1.0", 0},
+		{Maven, "1.0-sp1", "1.0", 1},
+		{Maven, "1.0-1", "1.0", 1},
+		{Maven, "1.0.1", "1.0-sp", 1},
+		{Rubygems, "1.0.0.pre", "1.0.0", -1},
+		{Rubygems, "1.0.0-rc1", "1.0.0", -1},
+		{Rubygems, "1.0.a", "1.0.b", -1},
+		{Rubygems, "1.10", "1.9", 1},
+	}
+	for _, tt := range tests {
+		if got := CompareEcosystemVersions(tt.pkgType, tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareEcosystemVersions(%v, %q, %q) got = %v, want %v", tt.pkgType, tt.a, tt.b, got, tt.want)
+		}
+		if got := CompareEcosystemVersions(tt.pkgType, tt.b, tt.a); got != -tt.want {
+			t.Errorf("CompareEcosystemVersions(%v, %q, %q) got = %v, want %v", tt.pkgType, tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
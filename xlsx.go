@@ -0,0 +1,136 @@
+package phylum
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`</Relationships>`
+
+// The header row is frozen so it stays visible while scrolling
+const xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>` +
+	`<sheetData>`
+
+const xlsxSheetEnd = `</sheetData></worksheet>`
+
+// xlsxWriter streams rows into a single-sheet workbook. The static parts of the package are
+// written up front so the worksheet is the last zip entry and can be written row by row.
+type xlsxWriter struct {
+	zip     *zip.Writer
+	sheet   *bufio.Writer
+	numeric []bool // Columns written as numbers when their value parses as one
+	row     int
+}
+
+func newXLSXWriter(w io.Writer, sheetName string, numeric []bool) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, xlsxEscape(sheetName))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	bw := bufio.NewWriter(sheet)
+	if _, err := bw.WriteString(xlsxSheetStart); err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{zip: zw, sheet: bw, numeric: numeric}, nil
+}
+
+// Write appends a row. Values in numeric columns are written as numbers; the header row and
+// anything else are inline strings.
+func (x *xlsxWriter) Write(record []string) error {
+	x.row++
+	if _, err := fmt.Fprintf(x.sheet, `<row r="%d">`, x.row); err != nil {
+		return err
+	}
+	for i, value := range record {
+		ref := xlsxColumn(i) + strconv.Itoa(x.row)
+		if value == "" {
+			continue
+		}
+		var err error
+		if _, parseErr := strconv.ParseFloat(value, 64); x.row > 1 && i < len(x.numeric) && x.numeric[i] && parseErr == nil {
+			_, err = fmt.Fprintf(x.sheet, `<c r="%s"><v>%s</v></c>`, ref, value)
+		} else {
+			_, err = fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xlsxEscape(value))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+// Close finishes the worksheet and the zip archive. It does not close the underlying writer.
+func (x *xlsxWriter) Close() error {
+	if _, err := x.sheet.WriteString(xlsxSheetEnd); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// xlsxColumn converts a zero-based column index to its letters: 0 is A, 26 is AA
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxEscape escapes text for XML, replacing characters XML cannot represent
+func xlsxEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}